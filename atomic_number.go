@@ -0,0 +1,166 @@
+package tsync
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+type Float interface {
+	~float32 | ~float64
+}
+
+// AtomicNumber 以 uint64 位模式存储数值：整数的 Add/Sub 直接使用原子加法，
+// 浮点数以及 StoreMax/StoreMin/And/Or 使用 CAS 循环。零值可直接使用。
+type AtomicNumber[T Integer | Float] struct {
+	v atomic.Uint64
+}
+
+func NewAtomicNumber[T Integer | Float](v T) *AtomicNumber[T] {
+	n := &AtomicNumber[T]{}
+	n.Store(v)
+	return n
+}
+
+func (n *AtomicNumber[T]) Load() T {
+	return fromBits[T](n.v.Load())
+}
+
+func (n *AtomicNumber[T]) Store(v T) {
+	n.v.Store(toBits(v))
+}
+
+func (n *AtomicNumber[T]) Swap(v T) (old T) {
+	return fromBits[T](n.v.Swap(toBits(v)))
+}
+
+func (n *AtomicNumber[T]) CompareAndSwap(old, new T) (swapped bool) {
+	for {
+		raw := n.v.Load()
+		if fromBits[T](raw) != old {
+			return false
+		}
+		if n.v.CompareAndSwap(raw, toBits(new)) {
+			return true
+		}
+	}
+}
+
+// Add 加上 delta 并返回新值。
+func (n *AtomicNumber[T]) Add(delta T) (new T) {
+	if !isFloat[T]() {
+		return T(n.v.Add(uint64(delta)))
+	}
+	return n.update(func(cur T) T {
+		return cur + delta
+	})
+}
+
+// Sub 减去 delta 并返回新值。
+func (n *AtomicNumber[T]) Sub(delta T) (new T) {
+	if !isFloat[T]() {
+		// 加上 delta 的补码
+		return T(n.v.Add(^(uint64(delta) - 1)))
+	}
+	return n.update(func(cur T) T {
+		return cur - delta
+	})
+}
+
+func (n *AtomicNumber[T]) Inc() (new T) {
+	return n.Add(1)
+}
+
+func (n *AtomicNumber[T]) Dec() (new T) {
+	return n.Sub(1)
+}
+
+// StoreMax 在 v 大于当前值时存储 v，并报告是否存储。
+func (n *AtomicNumber[T]) StoreMax(v T) (stored bool) {
+	for {
+		raw := n.v.Load()
+		if !(v > fromBits[T](raw)) {
+			return false
+		}
+		if n.v.CompareAndSwap(raw, toBits(v)) {
+			return true
+		}
+	}
+}
+
+// StoreMin 在 v 小于当前值时存储 v，并报告是否存储。
+func (n *AtomicNumber[T]) StoreMin(v T) (stored bool) {
+	for {
+		raw := n.v.Load()
+		if !(v < fromBits[T](raw)) {
+			return false
+		}
+		if n.v.CompareAndSwap(raw, toBits(v)) {
+			return true
+		}
+	}
+}
+
+// And 按位与 mask 并返回旧值，T 为浮点数时 panic。
+func (n *AtomicNumber[T]) And(mask T) (old T) {
+	if isFloat[T]() {
+		panic("tsync.AtomicNumber: And on floating-point type")
+	}
+	return n.bitwise(func(raw uint64) uint64 {
+		return raw & uint64(mask)
+	})
+}
+
+// Or 按位或 mask 并返回旧值，T 为浮点数时 panic。
+func (n *AtomicNumber[T]) Or(mask T) (old T) {
+	if isFloat[T]() {
+		panic("tsync.AtomicNumber: Or on floating-point type")
+	}
+	return n.bitwise(func(raw uint64) uint64 {
+		return raw | uint64(mask)
+	})
+}
+
+func (n *AtomicNumber[T]) update(fn func(cur T) T) T {
+	for {
+		raw := n.v.Load()
+		v := fn(fromBits[T](raw))
+		if n.v.CompareAndSwap(raw, toBits(v)) {
+			return v
+		}
+	}
+}
+
+func (n *AtomicNumber[T]) bitwise(fn func(raw uint64) uint64) T {
+	for {
+		raw := n.v.Load()
+		if n.v.CompareAndSwap(raw, fn(raw)) {
+			return fromBits[T](raw)
+		}
+	}
+}
+
+func isFloat[T Integer | Float]() bool {
+	var x T = 1
+	x /= 2
+	return x != 0
+}
+
+func toBits[T Integer | Float](v T) uint64 {
+	if isFloat[T]() {
+		return math.Float64bits(float64(v))
+	}
+	return uint64(v)
+}
+
+// fromBits 对整数按位截断，因此 Add 产生的溢出高位不影响结果。
+func fromBits[T Integer | Float](raw uint64) T {
+	if isFloat[T]() {
+		return T(math.Float64frombits(raw))
+	}
+	return T(raw)
+}
@@ -0,0 +1,176 @@
+package tsync
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestAtomicNumber_LoadStore(t *testing.T) {
+	n := NewAtomicNumber(int64(10))
+
+	if v := n.Load(); v != 10 {
+		t.Fatalf("expected 10, got %d", v)
+	}
+
+	n.Store(-20)
+	if v := n.Load(); v != -20 {
+		t.Fatalf("expected -20, got %d", v)
+	}
+
+	if old := n.Swap(5); old != -20 {
+		t.Fatalf("expected old=-20, got %d", old)
+	}
+}
+
+func TestAtomicNumber_ZeroValue(t *testing.T) {
+	var i AtomicNumber[int]
+	var f AtomicNumber[float64]
+
+	if i.Inc() != 1 {
+		t.Fatalf("expected 1, got %d", i.Load())
+	}
+	if f.Add(1.5) != 1.5 {
+		t.Fatalf("expected 1.5, got %v", f.Load())
+	}
+}
+
+func TestAtomicNumber_AddSub(t *testing.T) {
+	n := NewAtomicNumber(uint32(10))
+
+	if v := n.Add(5); v != 15 {
+		t.Fatalf("expected 15, got %d", v)
+	}
+	if v := n.Sub(3); v != 12 {
+		t.Fatalf("expected 12, got %d", v)
+	}
+	if v := n.Dec(); v != 11 {
+		t.Fatalf("expected 11, got %d", v)
+	}
+}
+
+func TestAtomicNumber_SmallIntOverflow(t *testing.T) {
+	n := NewAtomicNumber(int8(127))
+
+	if v := n.Inc(); v != math.MinInt8 {
+		t.Fatalf("expected %d, got %d", math.MinInt8, v)
+	}
+	if !n.CompareAndSwap(math.MinInt8, 0) {
+		t.Fatalf("expected swap to succeed")
+	}
+	if v := n.Sub(1); v != -1 {
+		t.Fatalf("expected -1, got %d", v)
+	}
+
+	u := NewAtomicNumber(uint8(0))
+	if v := u.Dec(); v != math.MaxUint8 {
+		t.Fatalf("expected %d, got %d", math.MaxUint8, v)
+	}
+}
+
+func TestAtomicNumber_Float(t *testing.T) {
+	n := NewAtomicNumber(float32(1.5))
+
+	if v := n.Add(0.25); v != 1.75 {
+		t.Fatalf("expected 1.75, got %v", v)
+	}
+	if v := n.Sub(2); v != -0.25 {
+		t.Fatalf("expected -0.25, got %v", v)
+	}
+	if !n.CompareAndSwap(-0.25, 3) {
+		t.Fatalf("expected swap to succeed")
+	}
+	if v := n.Load(); v != 3 {
+		t.Fatalf("expected 3, got %v", v)
+	}
+}
+
+func TestAtomicNumber_StoreMaxMin(t *testing.T) {
+	n := NewAtomicNumber(10)
+
+	if n.StoreMax(5) {
+		t.Fatalf("expected StoreMax(5) to be ignored")
+	}
+	if !n.StoreMax(20) || n.Load() != 20 {
+		t.Fatalf("expected 20, got %d", n.Load())
+	}
+	if n.StoreMin(30) {
+		t.Fatalf("expected StoreMin(30) to be ignored")
+	}
+	if !n.StoreMin(-1) || n.Load() != -1 {
+		t.Fatalf("expected -1, got %d", n.Load())
+	}
+
+	f := NewAtomicNumber(1.0)
+	if !f.StoreMax(2.5) || f.Load() != 2.5 {
+		t.Fatalf("expected 2.5, got %v", f.Load())
+	}
+}
+
+func TestAtomicNumber_AndOr(t *testing.T) {
+	type flags uint16
+	const (
+		flagA flags = 1 << iota
+		flagB
+		flagC
+	)
+
+	n := NewAtomicNumber(flagA)
+
+	if old := n.Or(flagB | flagC); old != flagA {
+		t.Fatalf("expected old=%d, got %d", flagA, old)
+	}
+	if old := n.And(^flagB); old != flagA|flagB|flagC {
+		t.Fatalf("unexpected old value %d", old)
+	}
+	if v := n.Load(); v != flagA|flagC {
+		t.Fatalf("expected %d, got %d", flagA|flagC, v)
+	}
+}
+
+func TestAtomicNumber_AndFloat_Panic(t *testing.T) {
+	var n AtomicNumber[float64]
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	n.And(1)
+}
+
+func TestAtomicNumber_Concurrent(t *testing.T) {
+	var i AtomicNumber[int64]
+	var f AtomicNumber[float64]
+	var hi AtomicNumber[int]
+
+	const goroutines = 20
+	const iterations = 100
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for g := 0; g < goroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				i.Inc()
+				f.Add(0.5)
+				hi.StoreMax(g*iterations + j)
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	if v := i.Load(); v != goroutines*iterations {
+		t.Fatalf("expected %d, got %d", goroutines*iterations, v)
+	}
+	if v := f.Load(); v != goroutines*iterations/2 {
+		t.Fatalf("expected %d, got %v", goroutines*iterations/2, v)
+	}
+	if v := hi.Load(); v != goroutines*iterations-1 {
+		t.Fatalf("expected %d, got %d", goroutines*iterations-1, v)
+	}
+}
@@ -2,28 +2,95 @@ package tsync
 
 import "sync/atomic"
 
+// AtomicValue 将值装箱后通过 atomic.Pointer 存储，因此 T 可以是任意类型，
+// 包括接口类型以及 nil 接口值；代价是每次 Store/Swap 都会分配一个新的盒子。
 type AtomicValue[T any] struct {
-	v atomic.Value
+	p atomic.Pointer[T]
 }
 
 func NewAtomicValue[T any](v T) *AtomicValue[T] {
 	a := &AtomicValue[T]{}
-	a.v.Store(v)
+	a.p.Store(&v)
 	return a
 }
 
 func (a *AtomicValue[T]) Load() T {
-	return a.v.Load().(T)
+	v, _ := a.LoadOk()
+	return v
+}
+
+// LoadOk 与 Load 相同，但在从未存储过值时返回 false。
+func (a *AtomicValue[T]) LoadOk() (T, bool) {
+	if p := a.p.Load(); p != nil {
+		return *p, true
+	}
+	var zero T
+	return zero, false
 }
 
 func (a *AtomicValue[T]) Store(v T) {
-	a.v.Store(v)
+	a.p.Store(&v)
 }
 
 func (a *AtomicValue[T]) Swap(v T) (old T) {
-	return a.v.Swap(v).(T)
+	if p := a.p.Swap(&v); p != nil {
+		return *p
+	}
+	return old
+}
+
+// CompareAndSwapFunc 在 equal(当前值, old) 为 true 时存储 new。
+// T 可比较时可以使用 ComparableAtomicValue.CompareAndSwap。
+func (a *AtomicValue[T]) CompareAndSwapFunc(old, new T, equal func(x, y T) bool) (swapped bool) {
+	np := &new
+	for {
+		p := a.p.Load()
+
+		// 未存储过值时视为零值
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		if !equal(cur, old) {
+			return false
+		}
+		if a.p.CompareAndSwap(p, np) {
+			return true
+		}
+	}
+}
+
+// Update 以 CAS 重试循环原子地应用 fn，并返回新值。
+// fn 可能被调用多次，不应有副作用。
+func (a *AtomicValue[T]) Update(fn func(old T) T) T {
+	for {
+		p := a.p.Load()
+
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		v := fn(cur)
+		if a.p.CompareAndSwap(p, &v) {
+			return v
+		}
+	}
+}
+
+// ComparableAtomicValue 是 T 可比较的 AtomicValue，提供用 == 比较的 CompareAndSwap。
+// AtomicValue 本身不提供 CompareAndSwap，因此对 slice、map 等类型的误用在编译期即被拒绝。
+type ComparableAtomicValue[T comparable] struct {
+	AtomicValue[T]
+}
+
+func NewComparableAtomicValue[T comparable](v T) *ComparableAtomicValue[T] {
+	a := &ComparableAtomicValue[T]{}
+	a.Store(v)
+	return a
 }
 
-func (a *AtomicValue[T]) CompareAndSwap(old, new T) (swapped bool) {
-	return a.v.CompareAndSwap(old, new)
+func (a *ComparableAtomicValue[T]) CompareAndSwap(old, new T) (swapped bool) {
+	return a.CompareAndSwapFunc(old, new, func(x, y T) bool {
+		return x == y
+	})
 }
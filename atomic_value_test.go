@@ -1,6 +1,11 @@
 package tsync
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -122,7 +127,7 @@ func TestAtomicValue_ConcurrentSwap(t *testing.T) {
 }
 
 func TestAtomicValue_CompareAndSwap(t *testing.T) {
-	a := NewAtomicValue(10)
+	a := NewComparableAtomicValue(10)
 
 	swapped := a.CompareAndSwap(10, 20)
 	if !swapped {
@@ -142,7 +147,7 @@ func TestAtomicValue_CompareAndSwap(t *testing.T) {
 }
 
 func TestAtomicValue_ConcurrentCompareAndSwap(t *testing.T) {
-	a := NewAtomicValue(0)
+	a := NewComparableAtomicValue(0)
 
 	const goroutines = 100
 	const iterations = 100
@@ -174,7 +179,7 @@ func TestAtomicValue_CompareAndSwap_GenericType(t *testing.T) {
 		Name string
 	}
 
-	a := NewAtomicValue(user{
+	a := NewComparableAtomicValue(user{
 		ID:   1,
 		Name: "user1",
 	})
@@ -197,14 +202,214 @@ func TestAtomicValue_CompareAndSwap_GenericType(t *testing.T) {
 	}
 }
 
-func TestAtomicValue_Load_Uninitialized_Panic(t *testing.T) {
+func TestAtomicValue_ZeroValue(t *testing.T) {
+	var a AtomicValue[int]
+
+	if v := a.Load(); v != 0 {
+		t.Fatalf("expected 0, got %d", v)
+	}
+	if _, ok := a.LoadOk(); ok {
+		t.Fatalf("expected unset value")
+	}
+
+	if old := a.Swap(1); old != 0 {
+		t.Fatalf("expected old=0, got %d", old)
+	}
+	if v, ok := a.LoadOk(); !ok || v != 1 {
+		t.Fatalf("expected 1, got %d (ok=%v)", v, ok)
+	}
+}
+
+func TestAtomicValue_ZeroValue_CompareAndSwap(t *testing.T) {
+	var a ComparableAtomicValue[string]
+
+	if a.CompareAndSwap("x", "y") {
+		t.Fatalf("expected swap to fail")
+	}
+	if !a.CompareAndSwap("", "y") {
+		t.Fatalf("expected swap from zero to succeed")
+	}
+	if v := a.Load(); v != "y" {
+		t.Fatalf("expected y, got %q", v)
+	}
+	if a.CompareAndSwap("", "z") {
+		t.Fatalf("expected swap to fail")
+	}
+}
+
+func TestAtomicValue_InterfaceType(t *testing.T) {
+	errA := errors.New("a")
+	errB := io.EOF
+
+	var a AtomicValue[error]
+
+	a.Store(errA)
+	if err := a.Load(); err != errA {
+		t.Fatalf("expected %v, got %v", errA, err)
+	}
+
+	// 不同的具体类型
+	a.Store(fmt.Errorf("wrapped: %w", errA))
+	a.Store(errB)
+	if err := a.Load(); err != errB {
+		t.Fatalf("expected %v, got %v", errB, err)
+	}
+
+	// nil 接口值
+	a.Store(nil)
+	if err, ok := a.LoadOk(); err != nil || !ok {
+		t.Fatalf("expected stored nil, got %v (ok=%v)", err, ok)
+	}
+
+	if !a.CompareAndSwapFunc(nil, errA, func(x, y error) bool { return x == y }) {
+		t.Fatalf("expected swap from nil to succeed")
+	}
+	if old := a.Swap(nil); old != errA {
+		t.Fatalf("expected old=%v, got %v", errA, old)
+	}
+}
+
+func TestAtomicValue_InterfaceType_Reader(t *testing.T) {
+	a := NewAtomicValue[io.Reader](strings.NewReader("x"))
+
+	a.Store(bytes.NewReader(nil))
+
+	if _, ok := a.Load().(*bytes.Reader); !ok {
+		t.Fatalf("unexpected value %T", a.Load())
+	}
+}
+
+func TestAtomicValue_Update(t *testing.T) {
 	var a AtomicValue[int]
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Fatalf("expected panic")
+	const goroutines = 20
+	const iterations = 100
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				a.Update(func(old int) int {
+					return old + 1
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if v := a.Load(); v != goroutines*iterations {
+		t.Fatalf("expected %d, got %d", goroutines*iterations, v)
+	}
+}
+
+func TestAtomicValue_Update_NonComparable(t *testing.T) {
+	a := NewAtomicValue([]string{"a"})
+
+	v := a.Update(func(old []string) []string {
+		return append(old[:len(old):len(old)], "b")
+	})
+
+	if len(v) != 2 || v[1] != "b" {
+		t.Fatalf("unexpected value %v", v)
+	}
+}
+
+func TestAtomicValue_CompareAndSwapFunc(t *testing.T) {
+	sameLen := func(x, y []int) bool {
+		return len(x) == len(y)
+	}
+
+	a := NewAtomicValue([]int{1, 2})
+
+	if a.CompareAndSwapFunc([]int{1}, []int{3}, sameLen) {
+		t.Fatalf("expected swap to fail")
+	}
+	if !a.CompareAndSwapFunc([]int{9, 9}, []int{3}, sameLen) {
+		t.Fatalf("expected swap to succeed")
+	}
+	if v := a.Load(); len(v) != 1 || v[0] != 3 {
+		t.Fatalf("unexpected value %v", v)
+	}
+}
+
+func TestComparableAtomicValue_CompareAndSwap(t *testing.T) {
+	a := NewComparableAtomicValue("a")
+
+	if !a.CompareAndSwap("a", "b") {
+		t.Fatalf("expected swap to succeed")
+	}
+	if a.CompareAndSwap("a", "c") {
+		t.Fatalf("expected swap to fail")
+	}
+	if v := a.Load(); v != "b" {
+		t.Fatalf("expected b, got %q", v)
+	}
+
+	var zero ComparableAtomicValue[int]
+	if !zero.CompareAndSwap(0, 1) {
+		t.Fatalf("expected swap from zero to succeed")
+	}
+}
+
+func BenchmarkAtomicValue_Load(b *testing.B) {
+	a := NewAtomicValue(42)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = a.Load()
 		}
-	}()
+	})
+}
+
+func BenchmarkAtomicValue_Load_StdValue(b *testing.B) {
+	var v atomic.Value
+	v.Store(42)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = v.Load().(int)
+		}
+	})
+}
 
-	_ = a.Load()
+func BenchmarkAtomicValue_Store(b *testing.B) {
+	a := NewAtomicValue(42)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a.Store(i)
+	}
+}
+
+func BenchmarkAtomicValue_Store_StdValue(b *testing.B) {
+	var v atomic.Value
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.Store(i)
+	}
+}
+
+func BenchmarkAtomicValue_Store_Struct(b *testing.B) {
+	type config struct {
+		Name    string
+		Version int
+	}
+	a := NewAtomicValue(config{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a.Store(config{Name: "v", Version: i})
+	}
+}
+
+func BenchmarkAtomicValue_Store_Struct_StdValue(b *testing.B) {
+	type config struct {
+		Name    string
+		Version int
+	}
+	var v atomic.Value
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v.Store(config{Name: "v", Version: i})
+	}
 }
@@ -0,0 +1,128 @@
+package tsync
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// changeHooks 保存 OnChange 注册的回调。修改在持有值的锁时按顺序入队，
+// 释放锁后由某个 goroutine 依次取出、比较并调用回调，因此回调不在临界区内执行，
+// 且观察到的顺序与修改顺序一致。没有回调时写路径只多一次原子读。
+type changeHooks[T any] struct {
+	clone func(T) T
+	equal func(a, b T) bool
+
+	n        atomic.Int32
+	mu       sync.Mutex
+	hooks    []*changeHook[T] // 写时复制，通知时无需持有 mu
+	pending  []change[T]
+	draining bool
+}
+
+type changeHook[T any] struct {
+	fn      func(old, new T)
+	removed atomic.Bool
+}
+
+type change[T any] struct {
+	old, new T
+}
+
+func (c *changeHooks[T]) add(fn func(old, new T)) (remove func()) {
+	h := &changeHook[T]{fn: fn}
+
+	c.mu.Lock()
+	c.hooks = append(c.hooks[:len(c.hooks):len(c.hooks)], h)
+	c.n.Add(1)
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.removed.Store(true)
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			hooks := make([]*changeHook[T], 0, len(c.hooks)-1)
+			for _, other := range c.hooks {
+				if other != h {
+					hooks = append(hooks, other)
+				}
+			}
+			c.hooks = hooks
+			c.n.Add(-1)
+		})
+	}
+}
+
+// snapshot 需持有值的锁，在修改前调用。没有回调时返回 nil。
+func (c *changeHooks[T]) snapshot(v *T) *T {
+	if c.n.Load() == 0 {
+		return nil
+	}
+	old := c.copy(*v)
+	return &old
+}
+
+// record 需持有值的锁，在修改后调用。
+func (c *changeHooks[T]) record(old T, new T) {
+	c.mu.Lock()
+	c.pending = append(c.pending, change[T]{old: old, new: c.copy(new)})
+	c.mu.Unlock()
+}
+
+// notify 需在释放值的锁之后调用。已有其他 goroutine 在通知时直接返回，
+// 由它继续处理新入队的修改。
+func (c *changeHooks[T]) notify() {
+	c.mu.Lock()
+	if c.draining {
+		c.mu.Unlock()
+		return
+	}
+	c.draining = true
+
+	// 回调 panic 时也要清除 draining，剩余的修改留给下一次通知
+	locked := true
+	defer func() {
+		if !locked {
+			c.mu.Lock()
+		}
+		c.draining = false
+		c.mu.Unlock()
+	}()
+
+	for len(c.pending) > 0 {
+		ch := c.pending[0]
+		c.pending[0] = change[T]{}
+		c.pending = c.pending[1:]
+		hooks := c.hooks
+		c.mu.Unlock()
+		locked = false
+
+		if !c.equalValues(ch.old, ch.new) {
+			for _, h := range hooks {
+				if !h.removed.Load() {
+					h.fn(ch.old, ch.new)
+				}
+			}
+		}
+
+		c.mu.Lock()
+		locked = true
+	}
+}
+
+func (c *changeHooks[T]) copy(v T) T {
+	if c.clone != nil {
+		return c.clone(v)
+	}
+	return v
+}
+
+func (c *changeHooks[T]) equalValues(a, b T) bool {
+	if c.equal != nil {
+		return c.equal(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
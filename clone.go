@@ -0,0 +1,105 @@
+package tsync
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Cloner 由能够自我深拷贝的类型实现。未通过 WithClone 指定拷贝函数时，
+// 若 T 或 *T 实现了 Cloner[T]，MutexValue 和 RWMutexValue 会自动使用它。
+type Cloner[T any] interface {
+	Clone() T
+}
+
+// DeepCopy 基于反射递归复制 v，包括指针、slice、map、接口和未导出字段；
+// chan、func 等无法复制的值按原样共享，指针环会被保留。
+// 它比手写的拷贝函数慢得多，需要时通过 WithClone(DeepCopy[T]) 显式启用。
+func DeepCopy[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.New(src.Type()).Elem()
+	deepCopy(dst, src, make(map[deepCopyKey]reflect.Value))
+	return *dst.Addr().Interface().(*T)
+}
+
+type deepCopyKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+func deepCopy(dst, src reflect.Value, seen map[deepCopyKey]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		key := deepCopyKey{ptr: src.Pointer(), typ: src.Type()}
+		if p, ok := seen[key]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		seen[key] = p
+		deepCopy(p.Elem(), src.Elem(), seen)
+		dst.Set(p)
+
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := src.Elem()
+		c := reflect.New(elem.Type()).Elem()
+		deepCopy(c, elem, seen)
+		dst.Set(c)
+
+	case reflect.Struct:
+		if !src.CanAddr() {
+			tmp := reflect.New(src.Type()).Elem()
+			tmp.Set(src)
+			src = tmp
+		}
+		for i := 0; i < src.NumField(); i++ {
+			deepCopy(settable(dst.Field(i)), settable(src.Field(i)), seen)
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(s.Index(i), src.Index(i), seen)
+		}
+		dst.Set(s)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			deepCopy(dst.Index(i), src.Index(i), seen)
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(src.Type().Key()).Elem()
+			deepCopy(k, iter.Key(), seen)
+			v := reflect.New(src.Type().Elem()).Elem()
+			deepCopy(v, iter.Value(), seen)
+			m.SetMapIndex(k, v)
+		}
+		dst.Set(m)
+
+	default:
+		dst.Set(src)
+	}
+}
+
+// settable 去掉未导出字段的只读标记，v 必须可寻址。
+func settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
@@ -0,0 +1,80 @@
+package tsync
+
+import (
+	"testing"
+)
+
+type deepCopyNode struct {
+	Name     string
+	Children []*deepCopyNode
+	Parent   *deepCopyNode
+	tags     map[string][]int
+	Extra    any
+}
+
+func TestDeepCopy_Nested(t *testing.T) {
+	root := &deepCopyNode{Name: "root", tags: map[string][]int{"a": {1, 2}}}
+	child := &deepCopyNode{Name: "child", Parent: root, Extra: []string{"x"}}
+	root.Children = append(root.Children, child)
+
+	c := DeepCopy(root)
+
+	if c == root || c.Children[0] == child {
+		t.Fatalf("expected pointers to be copied")
+	}
+	if c.Children[0].Parent != c {
+		t.Fatalf("expected pointer cycle to be preserved")
+	}
+
+	root.tags["a"][0] = 100
+	child.Extra.([]string)[0] = "y"
+
+	if c.tags["a"][0] != 1 {
+		t.Fatalf("expected unexported map to be deep copied, got %v", c.tags)
+	}
+	if c.Children[0].Extra.([]string)[0] != "x" {
+		t.Fatalf("expected interface value to be deep copied")
+	}
+}
+
+func TestDeepCopy_Basic(t *testing.T) {
+	if v := DeepCopy(42); v != 42 {
+		t.Fatalf("expected 42, got %d", v)
+	}
+
+	var err error
+	if v := DeepCopy(err); v != nil {
+		t.Fatalf("expected nil, got %v", v)
+	}
+
+	var m map[string]int
+	if v := DeepCopy(m); v != nil {
+		t.Fatalf("expected nil map, got %v", v)
+	}
+
+	arr := [2][]int{{1}, {2}}
+	c := DeepCopy(arr)
+	arr[0][0] = 9
+	if c[0][0] != 1 {
+		t.Fatalf("expected array elements to be deep copied")
+	}
+}
+
+type clonerConfig struct {
+	Hosts []string
+}
+
+func (c *clonerConfig) Clone() clonerConfig {
+	return clonerConfig{Hosts: append([]string(nil), c.Hosts...)}
+}
+
+func TestCloneOption_Cloner(t *testing.T) {
+	mv := NewMutexValue(clonerConfig{Hosts: []string{"a"}})
+
+	v := mv.Load()
+	v.Hosts[0] = "b"
+
+	if got := mv.Load().Hosts[0]; got != "a" {
+		t.Fatalf("expected Load to use Clone, got %q", got)
+	}
+}
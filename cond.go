@@ -6,14 +6,18 @@ import (
 )
 
 type Cond struct {
-	mu   sync.Mutex
+	mu   *Mutex
 	cond *sync.Cond
 }
 
 func NewCond() *Cond {
-	c := &Cond{}
-	c.cond = sync.NewCond(&c.mu)
-	return c
+	return NewCondWithMutex(&Mutex{})
+}
+
+// NewCondWithMutex 创建使用 m 的条件变量，predicate 在持有 m 时执行，
+// 因此可以与其他在 m 下修改状态的代码配合使用。
+func NewCondWithMutex(m *Mutex) *Cond {
+	return &Cond{mu: m, cond: sync.NewCond(m)}
 }
 
 func (c *Cond) WaitUntil(predicate func() bool) {
@@ -82,3 +82,21 @@ func TestCond_Signal(t *testing.T) {
 		t.Fatalf("signal did not wake waiter")
 	}
 }
+
+func TestCond_WithMutex(t *testing.T) {
+	m := NewMutex(WithFIFO())
+	c := NewCondWithMutex(m)
+
+	queue := 0
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		m.Lock()
+		queue++
+		m.Unlock()
+		c.Broadcast()
+	}()
+
+	c.WaitUntil(func() bool {
+		return queue > 0
+	})
+}
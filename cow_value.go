@@ -0,0 +1,49 @@
+package tsync
+
+import "sync"
+
+// COWValue 是写时复制容器：读取只有一次原子加载，得到的快照不会再被修改；
+// 写入通过 mu 串行化，在 clone 出的副本上修改后整体替换。
+type COWValue[T any] struct {
+	mu    sync.Mutex
+	v     AtomicValue[T]
+	clone func(v T) T
+}
+
+func NewCOWValue[T any](v T, clone func(v T) T) *COWValue[T] {
+	if clone == nil {
+		panic("tsync.COWValue: nil clone function")
+	}
+	c := &COWValue[T]{clone: clone}
+	c.v.Store(v)
+	return c
+}
+
+// Load 返回当前快照，调用方不得修改它。
+func (c *COWValue[T]) Load() T {
+	return c.v.Load()
+}
+
+func (c *COWValue[T]) Store(v T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.v.Store(v)
+}
+
+func (c *COWValue[T]) Swap(v T) (old T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.v.Swap(v)
+}
+
+// Update 把当前快照的副本交给 fn，并以 fn 的返回值作为新快照。
+func (c *COWValue[T]) Update(fn func(v T) T) T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v := fn(c.clone(c.v.Load()))
+	c.v.Store(v)
+	return v
+}
@@ -0,0 +1,88 @@
+package tsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func cloneRoutes(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func TestCOWValue_LoadUpdate(t *testing.T) {
+	c := NewCOWValue(map[string]string{"/": "index"}, cloneRoutes)
+
+	snapshot := c.Load()
+
+	c.Update(func(m map[string]string) map[string]string {
+		m["/about"] = "about"
+		return m
+	})
+
+	if _, ok := snapshot["/about"]; ok {
+		t.Fatalf("expected old snapshot to be unchanged")
+	}
+	if v := c.Load()["/about"]; v != "about" {
+		t.Fatalf("expected about, got %q", v)
+	}
+}
+
+func TestCOWValue_Store(t *testing.T) {
+	c := NewCOWValue(map[string]string{}, cloneRoutes)
+
+	c.Store(map[string]string{"/": "home"})
+
+	if v := c.Load()["/"]; v != "home" {
+		t.Fatalf("expected home, got %q", v)
+	}
+}
+
+func TestCOWValue_NilClone_Panic(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	NewCOWValue(1, nil)
+}
+
+func TestCOWValue_ConcurrentReadWrite(t *testing.T) {
+	c := NewCOWValue(map[string]string{}, cloneRoutes)
+
+	const writers = 10
+	const readers = 10
+
+	var wg sync.WaitGroup
+	wg.Add(writers + readers)
+
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			c.Update(func(m map[string]string) map[string]string {
+				m[string(rune('a'+i))] = "x"
+				return m
+			})
+		}(i)
+	}
+
+	for i := 0; i < readers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for range c.Load() {
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := len(c.Load()); n != writers {
+		t.Fatalf("expected %d routes, got %d", writers, n)
+	}
+}
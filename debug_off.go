@@ -0,0 +1,5 @@
+//go:build !tsync_debug
+
+package tsync
+
+const debugMode = false
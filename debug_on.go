@@ -0,0 +1,6 @@
+//go:build tsync_debug
+
+package tsync
+
+// debugMode 在使用 -tags tsync_debug 构建时开启额外的运行时检查。
+const debugMode = true
@@ -0,0 +1,52 @@
+package tsync
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// goid 从栈信息中解析当前 goroutine 的 id。开销较大，仅用于调试检查和 ReentrantMutexValue。
+func goid() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
+
+func callerStack() string {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// goroutineStack 返回指定 goroutine 当前的调用栈，该 goroutine 已退出时返回空串。
+func goroutineStack(id int64) string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+
+	prefix := "goroutine " + strconv.FormatInt(id, 10) + " ["
+	for _, s := range strings.Split(string(buf), "\n\n") {
+		if strings.HasPrefix(s, prefix) {
+			return s + "\n"
+		}
+	}
+	return ""
+}
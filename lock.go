@@ -0,0 +1,795 @@
+package tsync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var lastLockID atomic.Uint64
+
+// lockID 为每把锁惰性分配唯一编号，用于确定 LockAll 的获取顺序和调试检查；
+// 不按地址识别，避免地址被复用造成误判。
+type lockID struct {
+	id atomic.Uint64
+}
+
+func (l *lockID) get() uint64 {
+	if id := l.id.Load(); id != 0 {
+		return id
+	}
+	l.id.CompareAndSwap(0, lastLockID.Add(1))
+	return l.id.Load()
+}
+
+// Mutex 是可以在等待时被 ctx 取消的互斥锁，实现了 sync.Locker，
+// 可以与 sync.Cond 或 NewCondWithMutex 一起使用。零值可直接使用。
+// 未发生竞争时加锁和解锁各只需一次原子操作；需要阻塞时才进入内部的等待队列。
+// 与 sync.Mutex 一样，默认允许新到达的 goroutine 抢在被唤醒的等待者之前获得锁，
+// 吞吐量更高；等待超过 1ms 的等待者会在下一次解锁时直接获得锁，因此不会饿死。
+// 使用 NewMutex(WithFIFO()) 创建的 Mutex 总是按到达顺序把锁交给等待者。
+type Mutex struct {
+	state   atomic.Int32 // mutexLocked 位，加上等待者数量左移 mutexWaiterShift 位
+	mu      sync.Mutex   // 保护 waiters
+	waiters []*mutexWaiter
+	fifo    bool
+	obs     *lockObserver
+	id      lockID
+}
+
+var _ sync.Locker = (*Mutex)(nil)
+
+const (
+	mutexLocked      = 1
+	mutexWaiterShift = 1
+	mutexWaiterInc   = 1 << mutexWaiterShift
+
+	// mutexStarvation 是等待者被新到达的 goroutine 抢先的最长时间，
+	// 超过后解锁时直接把锁交给它。
+	mutexStarvation = time.Millisecond
+)
+
+type mutexWaiter struct {
+	since  time.Time
+	ready  chan bool // 被唤醒时收到一个值，true 表示锁已直接交给该等待者
+	queued bool      // 仍在 waiters 中，需持有 mu 访问
+}
+
+var mutexWaiterPool = sync.Pool{
+	New: func() any {
+		return &mutexWaiter{ready: make(chan bool, 1)}
+	},
+}
+
+// NewMutex 创建一个 Mutex，支持 WithFIFO、WithName、WithMetrics 和 WithHoldWarning。
+func NewMutex(opts ...Option) *Mutex {
+	m := newMutex(newOptions(opts))
+	return &m
+}
+
+func newMutex(o *options) Mutex {
+	return Mutex{fifo: o.fifo, obs: newLockObserver(o)}
+}
+
+func (m *Mutex) Lock() {
+	if debugMode {
+		lockOrder.before(m.id.get(), false)
+	}
+	if m.state.CompareAndSwap(0, mutexLocked) {
+		if m.obs != nil {
+			m.obs.acquired(lockWrite, time.Time{})
+		}
+	} else {
+		_ = m.lockSlow(context.Background())
+	}
+	if debugMode {
+		lockOrder.acquired(m.id.get())
+	}
+}
+
+// TryLock 仅在能立即获得锁时获得锁，并报告是否成功。
+func (m *Mutex) TryLock() bool {
+	if !m.tryAcquire() {
+		return false
+	}
+	if m.obs != nil {
+		m.obs.acquired(lockWrite, time.Time{})
+	}
+	if debugMode {
+		lockOrder.acquired(m.id.get())
+	}
+	return true
+}
+
+// LockCtx 获得锁，或在获得锁之前 ctx 结束时放弃并返回 ctx.Err()。
+func (m *Mutex) LockCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if debugMode {
+		lockOrder.before(m.id.get(), hasDeadline(ctx))
+	}
+	if m.state.CompareAndSwap(0, mutexLocked) {
+		if m.obs != nil {
+			m.obs.acquired(lockWrite, time.Time{})
+		}
+	} else if err := m.lockSlow(ctx); err != nil {
+		return err
+	}
+	if debugMode {
+		lockOrder.acquired(m.id.get())
+	}
+	return nil
+}
+
+// TryLockFor 在 d 时间内尝试获得锁，并报告是否成功。
+func (m *Mutex) TryLockFor(d time.Duration) bool {
+	if m.TryLock() {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return m.LockCtx(ctx) == nil
+}
+
+func (m *Mutex) Unlock() {
+	if debugMode {
+		lockOrder.released(m.id.get())
+	}
+	if m.obs != nil {
+		m.obs.released(lockWrite)
+	}
+	m.release()
+}
+
+// tryAcquire 在锁空闲时获得锁。WithFIFO 时有等待者则不插队。
+func (m *Mutex) tryAcquire() bool {
+	for {
+		s := m.state.Load()
+		if s&mutexLocked != 0 || (m.fifo && s != 0) {
+			return false
+		}
+		if m.state.CompareAndSwap(s, s|mutexLocked) {
+			return true
+		}
+	}
+}
+
+// lockSlow 在快速路径失败后排队等待，直到获得锁或 ctx 结束。
+func (m *Mutex) lockSlow(ctx context.Context) error {
+	var start time.Time
+	if m.obs != nil {
+		start = time.Now()
+		m.obs.waiting(1)
+		defer m.obs.waiting(-1)
+	}
+
+	w := mutexWaiterPool.Get().(*mutexWaiter)
+	w.since = time.Now()
+	defer mutexWaiterPool.Put(w)
+
+	for front := false; ; front = true {
+		if m.enqueue(w, front) {
+			break
+		}
+		var handoff bool
+		select {
+		case handoff = <-w.ready:
+		case <-ctx.Done():
+			return m.cancel(w, ctx.Err())
+		}
+		// 直接交接时锁已属于当前 goroutine；否则与新到达的 goroutine 竞争，失败则回到队首
+		if handoff || m.tryAcquire() {
+			break
+		}
+	}
+
+	if m.obs != nil {
+		m.obs.acquired(lockWrite, start)
+	}
+	return nil
+}
+
+// enqueue 在锁空闲时直接获得锁并返回 true，否则把 w 加入等待队列。
+func (m *Mutex) enqueue(w *mutexWaiter, front bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for {
+		s := m.state.Load()
+		if s&mutexLocked == 0 && !(m.fifo && s != 0) {
+			if m.state.CompareAndSwap(s, s|mutexLocked) {
+				return true
+			}
+			continue
+		}
+		if m.state.CompareAndSwap(s, s+mutexWaiterInc) {
+			break
+		}
+	}
+	if front {
+		m.waiters = append(m.waiters, nil)
+		copy(m.waiters[1:], m.waiters)
+		m.waiters[0] = w
+	} else {
+		m.waiters = append(m.waiters, w)
+	}
+	w.queued = true
+	return false
+}
+
+// cancel 处理等待期间 ctx 结束：仍在队列中则移除；已被唤醒则把锁或唤醒转交给下一个等待者。
+func (m *Mutex) cancel(w *mutexWaiter, err error) error {
+	m.mu.Lock()
+	if w.queued {
+		for i, other := range m.waiters {
+			if other == w {
+				m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+				break
+			}
+		}
+		w.queued = false
+		m.state.Add(-mutexWaiterInc)
+		m.mu.Unlock()
+		return err
+	}
+	m.mu.Unlock()
+
+	// 唤醒在持有 mu 时发送，此时已经在 ready 中
+	if <-w.ready {
+		m.release()
+	} else {
+		m.wake()
+	}
+	return err
+}
+
+// release 释放锁，有等待者时唤醒或直接交给其中一个。
+func (m *Mutex) release() {
+	if m.state.CompareAndSwap(mutexLocked, 0) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state.Load()&mutexLocked == 0 {
+		panic("tsync: unlock of unlocked mutex")
+	}
+	if len(m.waiters) == 0 {
+		m.state.Add(-mutexLocked)
+		return
+	}
+	w := m.pop()
+	if m.fifo || time.Since(w.since) >= mutexStarvation {
+		w.ready <- true
+		return
+	}
+	m.state.Add(-mutexLocked)
+	w.ready <- false
+}
+
+// wake 在锁空闲时唤醒一个等待者，用于被唤醒的等待者放弃时把唤醒转交出去。
+// 锁已被持有时由持有者解锁时唤醒。
+func (m *Mutex) wake() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.waiters) == 0 || m.state.Load()&mutexLocked != 0 {
+		return
+	}
+	m.pop().ready <- false
+}
+
+// pop 需持有 mu，取出队首的等待者。
+func (m *Mutex) pop() *mutexWaiter {
+	w := m.waiters[0]
+	m.waiters[0] = nil
+	m.waiters = m.waiters[1:]
+	w.queued = false
+	m.state.Add(-mutexWaiterInc)
+	return w
+}
+
+// RWMutex 是可以在等待时被 ctx 取消的读写锁，实现了 sync.Locker。
+// 默认策略 RWPhaseFair 下等待者按到达顺序排队，相邻的读者一起获得锁，
+// 因此写者不会被持续到来的读者饿死；其他策略见 RWPolicy。零值可直接使用。
+// 没有写者、可升级读者和等待者时，读锁的获取和释放各只需一次原子操作；
+// 否则所有操作都经过内部互斥锁，按策略排队。
+//
+// RWMutexValue 还通过它提供可升级读锁：同一时刻至多一个持有者，与普通读者共存，
+// 可以在不释放锁的情况下升级为写锁。
+type RWMutex struct {
+	state      atomic.Int64 // 读者数量左移 1 位，最低位 rwSlow 表示需要经过 mu
+	mu         sync.Mutex
+	writer     bool
+	upgradable bool          // 可升级读锁被持有（升级后仍为 true）
+	upgrade    chan struct{} // 可升级读者正在等待其他读者退出
+	queue      []*rwWaiter
+	writers    int // queue 中等待的写者数量
+	policy     RWPolicy
+	obs        *lockObserver
+	id         lockID
+}
+
+var _ sync.Locker = (*RWMutex)(nil)
+
+// RWPolicy 决定 RWMutex 在读者和写者之间如何分配锁。
+type RWPolicy uint8
+
+const (
+	// RWPhaseFair 按到达顺序排队，队首连续的读者一起获得锁，读者和写者都不会饿死。
+	RWPhaseFair RWPolicy = iota
+	// RWReaderPreferring 只要没有写者持有锁，新读者就立即进入，不理会等待中的写者。
+	// 读吞吐量最高，但读者持续到来时写者可能一直等待。
+	RWReaderPreferring
+	// RWWriterPreferring 有写者等待时新读者必须等待，释放锁时优先交给写者。
+	// 写者延迟最低，但写者持续到来时读者可能一直等待。
+	RWWriterPreferring
+)
+
+// NewRWMutex 创建一个 RWMutex，支持 WithRWPolicy、WithName、WithMetrics 和 WithHoldWarning。
+func NewRWMutex(opts ...Option) *RWMutex {
+	rw := newRWMutex(newOptions(opts))
+	return &rw
+}
+
+func newRWMutex(o *options) RWMutex {
+	return RWMutex{policy: o.policy, obs: newLockObserver(o)}
+}
+
+const (
+	rwSlow      = 1
+	rwReaderInc = 2
+)
+
+type lockKind uint8
+
+const (
+	lockRead lockKind = iota
+	lockWrite
+	lockUpgradable
+)
+
+type rwWaiter struct {
+	kind    lockKind
+	granted bool
+	ready   chan struct{}
+}
+
+func (rw *RWMutex) Lock() {
+	_ = rw.lock(context.Background(), lockWrite)
+}
+
+func (rw *RWMutex) RLock() {
+	_ = rw.lock(context.Background(), lockRead)
+}
+
+// LockCtx 获得写锁，或在获得锁之前 ctx 结束时放弃并返回 ctx.Err()。
+func (rw *RWMutex) LockCtx(ctx context.Context) error {
+	return rw.lock(ctx, lockWrite)
+}
+
+// RLockCtx 获得读锁，或在获得锁之前 ctx 结束时放弃并返回 ctx.Err()。
+func (rw *RWMutex) RLockCtx(ctx context.Context) error {
+	return rw.lock(ctx, lockRead)
+}
+
+// TryLock 仅在能立即获得写锁时获得锁，并报告是否成功。
+func (rw *RWMutex) TryLock() bool {
+	return rw.tryLock(lockWrite)
+}
+
+// TryRLock 仅在能立即获得读锁时获得锁，并报告是否成功。
+func (rw *RWMutex) TryRLock() bool {
+	return rw.tryLock(lockRead)
+}
+
+// TryLockFor 在 d 时间内尝试获得写锁，并报告是否成功。
+func (rw *RWMutex) TryLockFor(d time.Duration) bool {
+	return rw.tryLockFor(d, lockWrite)
+}
+
+// TryRLockFor 在 d 时间内尝试获得读锁，并报告是否成功。
+func (rw *RWMutex) TryRLockFor(d time.Duration) bool {
+	return rw.tryLockFor(d, lockRead)
+}
+
+// RLocker 返回以读锁实现 Lock 和 Unlock 的 sync.Locker。
+func (rw *RWMutex) RLocker() sync.Locker {
+	return (*rlocker)(rw)
+}
+
+type rlocker RWMutex
+
+func (r *rlocker) Lock()   { (*RWMutex)(r).RLock() }
+func (r *rlocker) Unlock() { (*RWMutex)(r).RUnlock() }
+
+func (rw *RWMutex) Unlock() {
+	rw.enter()
+	defer rw.leave()
+
+	if !rw.writer || rw.upgradable {
+		panic("tsync: unlock of unlocked rwmutex")
+	}
+	if debugMode {
+		lockOrder.released(rw.id.get())
+	}
+	if rw.obs != nil {
+		rw.obs.released(lockWrite)
+	}
+	rw.writer = false
+	rw.dispatch()
+}
+
+func (rw *RWMutex) RUnlock() {
+	s := rw.state.Add(-rwReaderInc)
+	if s < 0 {
+		rw.state.Add(rwReaderInc)
+		panic("tsync: runlock of unlocked rwmutex")
+	}
+	if debugMode {
+		lockOrder.released(rw.id.get())
+	}
+	if rw.obs != nil {
+		rw.obs.released(lockRead)
+	}
+	if s&rwSlow == 0 {
+		return
+	}
+
+	// 可能有写者或升级在等待读者退出
+	rw.enter()
+	defer rw.leave()
+	rw.dispatch()
+}
+
+// Downgrade 把持有的写锁原子地降级为读锁，期间其他写者无法插入。
+func (rw *RWMutex) Downgrade() {
+	rw.enter()
+	defer rw.leave()
+
+	if !rw.writer || rw.upgradable {
+		panic("tsync: downgrade of unlocked rwmutex")
+	}
+	if rw.obs != nil {
+		rw.obs.downgraded()
+	}
+	rw.writer = false
+	rw.state.Add(rwReaderInc)
+	rw.dispatch()
+}
+
+func (rw *RWMutex) upgradableRLock() {
+	_ = rw.lock(context.Background(), lockUpgradable)
+}
+
+func (rw *RWMutex) upgradableRUnlock() {
+	rw.enter()
+	defer rw.leave()
+
+	if !rw.upgradable || rw.writer {
+		panic("tsync: unlock of unlocked upgradable rwmutex")
+	}
+	if debugMode {
+		lockOrder.released(rw.id.get())
+	}
+	if rw.obs != nil {
+		rw.obs.released(lockUpgradable)
+	}
+	rw.upgradable = false
+	rw.dispatch()
+}
+
+// upgradeLock 把持有的可升级读锁升级为写锁，等待其他读者退出，期间不再接纳新读者。
+func (rw *RWMutex) upgradeLock() {
+	rw.enter()
+	if !rw.upgradable || rw.writer {
+		rw.leave()
+		panic("tsync: upgrade without upgradable rwmutex")
+	}
+	if rw.readers() == 0 {
+		rw.writer = true
+		rw.leave()
+		return
+	}
+
+	ch := make(chan struct{})
+	rw.upgrade = ch
+	rw.leave()
+	<-ch
+}
+
+// upgradeUnlock 把 upgradeLock 获得的写锁恢复为可升级读锁。
+func (rw *RWMutex) upgradeUnlock() {
+	rw.enter()
+	defer rw.leave()
+
+	if !rw.upgradable || !rw.writer {
+		panic("tsync: downgrade without upgraded rwmutex")
+	}
+	rw.writer = false
+	rw.dispatch()
+}
+
+func (rw *RWMutex) tryLock(kind lockKind) bool {
+	if kind == lockRead && rw.fastRLock() {
+		if rw.obs != nil {
+			rw.obs.acquired(lockRead, time.Time{})
+		}
+		if debugMode {
+			lockOrder.acquired(rw.id.get())
+		}
+		return true
+	}
+
+	rw.enter()
+	defer rw.leave()
+
+	if !rw.admit(kind) {
+		return false
+	}
+	rw.acquire(kind)
+	if rw.obs != nil {
+		rw.obs.acquired(kind, time.Time{})
+	}
+	if debugMode {
+		lockOrder.acquired(rw.id.get())
+	}
+	return true
+}
+
+func (rw *RWMutex) tryLockFor(d time.Duration, kind lockKind) bool {
+	if rw.tryLock(kind) {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return rw.lock(ctx, kind) == nil
+}
+
+func (rw *RWMutex) lock(ctx context.Context, kind lockKind) error {
+	if debugMode {
+		lockOrder.before(rw.id.get(), hasDeadline(ctx))
+	}
+	if err := rw.wait(ctx, kind); err != nil {
+		return err
+	}
+	if debugMode {
+		lockOrder.acquired(rw.id.get())
+	}
+	return nil
+}
+
+func (rw *RWMutex) wait(ctx context.Context, kind lockKind) error {
+	if kind == lockRead && rw.fastRLock() {
+		if rw.obs != nil {
+			rw.obs.acquired(lockRead, time.Time{})
+		}
+		return nil
+	}
+
+	rw.enter()
+	if rw.admit(kind) {
+		rw.acquire(kind)
+		rw.leave()
+		if rw.obs != nil {
+			rw.obs.acquired(kind, time.Time{})
+		}
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		rw.leave()
+		return err
+	}
+
+	w := &rwWaiter{kind: kind, ready: make(chan struct{})}
+	rw.queue = append(rw.queue, w)
+	if kind == lockWrite {
+		rw.writers++
+	}
+	rw.leave()
+
+	if rw.obs != nil {
+		start := time.Now()
+		rw.obs.waiting(1)
+		defer func() {
+			rw.obs.waiting(-1)
+			if w.granted {
+				rw.obs.acquired(kind, start)
+			}
+		}()
+	}
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	rw.enter()
+	defer rw.leave()
+
+	// 取消与获得锁同时发生时，以获得锁为准
+	if w.granted {
+		return nil
+	}
+	for i, q := range rw.queue {
+		if q == w {
+			rw.remove(i)
+			break
+		}
+	}
+	// 排在前面的写者离开后，后面的读者可能可以继续
+	rw.dispatch()
+	return ctx.Err()
+}
+
+// fastRLock 在没有写者、可升级读者和等待者时以一次原子操作获得读锁。
+func (rw *RWMutex) fastRLock() bool {
+	for {
+		s := rw.state.Load()
+		if s&rwSlow != 0 {
+			return false
+		}
+		if rw.state.CompareAndSwap(s, s+rwReaderInc) {
+			return true
+		}
+	}
+}
+
+// enter 获取 mu 并关闭读锁的快速路径，之后读者的进出都经过 mu。
+func (rw *RWMutex) enter() {
+	rw.mu.Lock()
+	for {
+		s := rw.state.Load()
+		if s&rwSlow != 0 || rw.state.CompareAndSwap(s, s|rwSlow) {
+			return
+		}
+	}
+}
+
+// leave 释放 mu；除读者外锁已空闲且没有等待者时重新开启快速路径。
+func (rw *RWMutex) leave() {
+	if !rw.writer && !rw.upgradable && rw.upgrade == nil && len(rw.queue) == 0 {
+		for {
+			s := rw.state.Load()
+			if s&rwSlow == 0 || rw.state.CompareAndSwap(s, s&^rwSlow) {
+				break
+			}
+		}
+	}
+	rw.mu.Unlock()
+}
+
+// 以下方法均需在持有 mu 时调用。
+
+// readers 返回当前持有读锁的数量。持有 mu 时它只可能因读者退出而减少，
+// 而减到 0 的读者随后会在 mu 下调用 dispatch。
+func (rw *RWMutex) readers() int64 {
+	return rw.state.Load() >> 1
+}
+
+func (rw *RWMutex) compatible(kind lockKind) bool {
+	if rw.writer {
+		return false
+	}
+	switch kind {
+	case lockRead:
+		return rw.upgrade == nil
+	case lockUpgradable:
+		return !rw.upgradable
+	default:
+		return rw.readers() == 0 && !rw.upgradable
+	}
+}
+
+func (rw *RWMutex) acquire(kind lockKind) {
+	switch kind {
+	case lockRead:
+		rw.state.Add(rwReaderInc)
+	case lockUpgradable:
+		rw.upgradable = true
+	default:
+		rw.writer = true
+	}
+}
+
+// admit 报告新到达的获取能否不排队直接获得锁。
+func (rw *RWMutex) admit(kind lockKind) bool {
+	if !rw.compatible(kind) {
+		return false
+	}
+	switch rw.policy {
+	case RWReaderPreferring:
+		return kind != lockWrite || len(rw.queue) == 0
+	case RWWriterPreferring:
+		return rw.writers == 0
+	default:
+		return len(rw.queue) == 0
+	}
+}
+
+// dispatch 在锁状态变化后唤醒等待者。等待升级的读者总是优先，其余按策略：
+// RWPhaseFair 按队列顺序，队首的写者在锁空闲时获得锁，队首连续的读者一起获得锁；
+// RWReaderPreferring 先放行所有读者，没有读者时才放行写者；
+// RWWriterPreferring 有写者等待时只放行写者。
+func (rw *RWMutex) dispatch() {
+	if rw.upgrade != nil {
+		if rw.readers() > 0 {
+			return
+		}
+		rw.writer = true
+		close(rw.upgrade)
+		rw.upgrade = nil
+		return
+	}
+
+	switch rw.policy {
+	case RWReaderPreferring:
+		rw.grantReaders()
+		rw.grantWriter()
+	case RWWriterPreferring:
+		if rw.writers > 0 {
+			rw.grantWriter()
+			return
+		}
+		rw.grantReaders()
+	default:
+		for len(rw.queue) > 0 {
+			w := rw.queue[0]
+			if !rw.compatible(w.kind) {
+				return
+			}
+			rw.grant(0)
+			if w.kind == lockWrite {
+				return
+			}
+		}
+	}
+}
+
+// grantReaders 放行队列中所有可以获得锁的读者和可升级读者。
+func (rw *RWMutex) grantReaders() {
+	for i := 0; i < len(rw.queue); {
+		if w := rw.queue[i]; w.kind != lockWrite && rw.compatible(w.kind) {
+			rw.grant(i)
+			continue
+		}
+		i++
+	}
+}
+
+// grantWriter 在锁空闲时放行队列中最早的写者。
+func (rw *RWMutex) grantWriter() {
+	if rw.writers == 0 || !rw.compatible(lockWrite) {
+		return
+	}
+	for i, w := range rw.queue {
+		if w.kind == lockWrite {
+			rw.grant(i)
+			return
+		}
+	}
+}
+
+func (rw *RWMutex) grant(i int) {
+	w := rw.queue[i]
+	rw.remove(i)
+	rw.acquire(w.kind)
+	w.granted = true
+	close(w.ready)
+}
+
+func (rw *RWMutex) remove(i int) {
+	if rw.queue[i].kind == lockWrite {
+		rw.writers--
+	}
+	if i == 0 {
+		rw.queue[0] = nil
+		rw.queue = rw.queue[1:]
+		return
+	}
+	rw.queue = append(rw.queue[:i], rw.queue[i+1:]...)
+}
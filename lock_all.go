@@ -0,0 +1,154 @@
+package tsync
+
+import "sort"
+
+// LockSpec 描述 LockAll 要获取的一把锁及获取后如何把值交给调用方，
+// 由 MutexValue.Spec、RWMutexValue.Spec 和 RWMutexValue.RSpec 创建。
+type LockSpec struct {
+	id      uint64
+	lock    func()
+	tryLock func() bool
+	unlock  func()
+	bind    func()
+	unbind  func()
+}
+
+// Spec 返回获取 m 的锁的 LockSpec。LockAll 执行 fn 期间 *p 指向锁内的值，
+// fn 返回后 *p 被置为 nil。
+func (m *MutexValue[T]) Spec(p **T) LockSpec {
+	var old *T
+	return LockSpec{
+		id: m.mu.id.get(),
+		lock: func() {
+			m.mu.Lock()
+			old = m.changes.snapshot(&m.v)
+		},
+		tryLock: func() bool {
+			if !m.mu.TryLock() {
+				return false
+			}
+			old = m.changes.snapshot(&m.v)
+			return true
+		},
+		unlock: func() {
+			m.unlock(old)
+		},
+		bind:   func() { *p = &m.v },
+		unbind: func() { *p = nil },
+	}
+}
+
+// Spec 返回获取 m 的写锁的 LockSpec，约定同 MutexValue.Spec。
+func (m *RWMutexValue[T]) Spec(p **T) LockSpec {
+	var old *T
+	return LockSpec{
+		id: m.mu.id.get(),
+		lock: func() {
+			m.mu.Lock()
+			old = m.changes.snapshot(&m.v)
+		},
+		tryLock: func() bool {
+			if !m.mu.TryLock() {
+				return false
+			}
+			old = m.changes.snapshot(&m.v)
+			return true
+		},
+		unlock: func() {
+			m.unlock(old)
+		},
+		bind:   func() { *p = &m.v },
+		unbind: func() { *p = nil },
+	}
+}
+
+// RSpec 返回获取 m 的读锁的 LockSpec。LockAll 执行 fn 之前把当前值复制到 *p，
+// 配置了拷贝函数时为深拷贝；fn 返回后 *p 被置为零值。
+func (m *RWMutexValue[T]) RSpec(p *T) LockSpec {
+	return LockSpec{
+		id:      m.mu.id.get(),
+		lock:    m.mu.RLock,
+		tryLock: m.mu.TryRLock,
+		unlock:  m.mu.RUnlock,
+		bind: func() {
+			if m.clone != nil {
+				*p = m.clone(m.v)
+				return
+			}
+			*p = m.v
+		},
+		unbind: func() {
+			var zero T
+			*p = zero
+		},
+	}
+}
+
+// LockAll 获取 specs 描述的所有锁后执行 fn，然后全部释放。
+// 锁按内部编号的全局一致顺序获取，并且只在不持有其他锁时阻塞：
+// 某把锁无法立即获得时先释放已持有的锁，再等待这把锁后重试，
+// 因此并发的 LockAll 调用之间，以及与单独加锁的调用之间都不会死锁。
+// 同一个值出现多次会 panic。
+func LockAll(fn func(), specs ...LockSpec) {
+	specs = append([]LockSpec(nil), specs...)
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].id < specs[j].id
+	})
+	for i := 1; i < len(specs); i++ {
+		if specs[i].id == specs[i-1].id {
+			panic("tsync: LockAll: the same value is specified more than once")
+		}
+	}
+
+	acquireAll(specs)
+	defer func() {
+		for i := len(specs) - 1; i >= 0; i-- {
+			specs[i].unbind()
+			specs[i].unlock()
+		}
+	}()
+
+	for _, s := range specs {
+		s.bind()
+	}
+	fn()
+}
+
+func acquireAll(specs []LockSpec) {
+	if len(specs) == 0 {
+		return
+	}
+
+	first := 0
+	for {
+		specs[first].lock()
+
+		failed := -1
+		for i := range specs {
+			if i != first && !specs[i].tryLock() {
+				failed = i
+				break
+			}
+		}
+		if failed < 0 {
+			return
+		}
+
+		for i := 0; i < failed; i++ {
+			if i != first {
+				specs[i].unlock()
+			}
+		}
+		specs[first].unlock()
+		first = failed
+	}
+}
+
+// Lock2 同时获取 a、b 的锁后执行 fn，获取方式同 LockAll。
+func Lock2[A, B any](a *MutexValue[A], b *MutexValue[B], fn func(a *A, b *B)) {
+	var pa *A
+	var pb *B
+	LockAll(func() {
+		fn(pa, pb)
+	}, a.Spec(&pa), b.Spec(&pb))
+}
@@ -0,0 +1,179 @@
+package tsync
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type account struct {
+	balance int
+}
+
+func TestLock2_Transfer(t *testing.T) {
+	a := NewMutexValue(account{balance: 1000})
+	b := NewMutexValue(account{balance: 1000})
+
+	transfer := func(from, to *MutexValue[account], amount int) {
+		Lock2(from, to, func(from, to *account) {
+			from.balance -= amount
+			to.balance += amount
+		})
+	}
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	wg.Add(goroutines * 2)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				transfer(a, b, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				transfer(b, a, 2)
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("transfers deadlocked")
+	}
+
+	if got := a.Load().balance + b.Load().balance; got != 2000 {
+		t.Fatalf("expected total 2000, got %d", got)
+	}
+	if got := a.Load().balance; got != 1000+goroutines*100 {
+		t.Fatalf("expected %d, got %d", 1000+goroutines*100, got)
+	}
+}
+
+func TestLockAll_MixedReadWrite(t *testing.T) {
+	rate := NewRWMutexValue(3)
+	src := NewRWMutexValue(10)
+	dst := NewMutexValue(0)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			var r int
+			var s, d *int
+			LockAll(func() {
+				*d += *s * r
+			}, rate.RSpec(&r), src.Spec(&s), dst.Spec(&d))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			var d *int
+			var s int
+			LockAll(func() {
+				*d -= s * 3
+			}, dst.Spec(&d), src.RSpec(&s))
+		}
+	}()
+	wg.Wait()
+
+	if got := dst.Load(); got != 0 {
+		t.Fatalf("expected 0, got %d", got)
+	}
+}
+
+func TestLockAll_BackOffWhenHeldElsewhere(t *testing.T) {
+	a := NewMutexValue(0)
+	b := NewMutexValue(0)
+
+	// 另一个 goroutine 以相反顺序单独加锁
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.Lock(func(vb *int) {
+				a.Lock(func(va *int) {
+					*va++
+					*vb++
+				})
+			})
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		Lock2(a, b, func(va, vb *int) {
+			*va++
+			*vb++
+		})
+	}
+	wg.Wait()
+
+	if a.Load() != 200 || b.Load() != 200 {
+		t.Fatalf("unexpected values %d %d", a.Load(), b.Load())
+	}
+}
+
+func TestLockAll_Unbind(t *testing.T) {
+	mv := NewMutexValue(1)
+
+	var p *int
+	LockAll(func() {
+		if p == nil || *p != 1 {
+			t.Fatalf("expected value to be bound")
+		}
+		*p = 2
+	}, mv.Spec(&p))
+
+	if p != nil {
+		t.Fatalf("expected pointer to be cleared after LockAll")
+	}
+	if got := mv.Load(); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+}
+
+func TestLockAll_RSpec_CloneAndUnbind(t *testing.T) {
+	mv := NewRWMutexValue([]int{1, 2, 3}, WithClone(func(v []int) []int {
+		return append([]int(nil), v...)
+	}))
+
+	var s []int
+	LockAll(func() {
+		if len(s) != 3 || s[0] != 1 {
+			t.Fatalf("expected value to be bound, got %v", s)
+		}
+		s[0] = 100
+	}, mv.RSpec(&s))
+
+	if s != nil {
+		t.Fatalf("expected value to be cleared after LockAll, got %v", s)
+	}
+	if got := mv.Load(); got[0] != 1 {
+		t.Fatalf("expected clone to protect the value, got %v", got)
+	}
+}
+
+func TestLockAll_Duplicate_Panic(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	var p *int
+	var v int
+	LockAll(func() {}, mv.Spec(&p), mv.RSpec(&v))
+}
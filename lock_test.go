@@ -0,0 +1,616 @@
+package tsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMutex_TryLock(t *testing.T) {
+	var m Mutex
+
+	if !m.TryLock() {
+		t.Fatalf("expected TryLock to succeed")
+	}
+	if m.TryLock() {
+		t.Fatalf("expected TryLock to fail")
+	}
+	m.Unlock()
+	if !m.TryLock() {
+		t.Fatalf("expected TryLock to succeed after Unlock")
+	}
+	m.Unlock()
+}
+
+func TestMutex_LockCtx_Cancel(t *testing.T) {
+	var m Mutex
+	m.Lock()
+	defer m.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := m.LockCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestMutex_Unlock_Unlocked_Panic(t *testing.T) {
+	var m Mutex
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	m.Unlock()
+}
+
+func TestRWMutex_TryLock(t *testing.T) {
+	var rw RWMutex
+
+	if !rw.TryRLock() || !rw.TryRLock() {
+		t.Fatalf("expected concurrent TryRLock to succeed")
+	}
+	if rw.TryLock() {
+		t.Fatalf("expected TryLock to fail while readers hold the lock")
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+
+	if !rw.TryLock() {
+		t.Fatalf("expected TryLock to succeed")
+	}
+	if rw.TryRLock() {
+		t.Fatalf("expected TryRLock to fail while writer holds the lock")
+	}
+	rw.Unlock()
+}
+
+func TestRWMutex_LockCtx_Cancel(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := rw.LockCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// 被取消的写者离开队列后，新的读者不再被阻塞
+	if !rw.TryRLock() {
+		t.Fatalf("expected TryRLock to succeed after writer gave up")
+	}
+	rw.RUnlock()
+	rw.RUnlock()
+}
+
+func TestRWMutex_CancelledWriterUnblocksReaders(t *testing.T) {
+	var rw RWMutex
+	rw.RLock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	writerDone := make(chan error)
+	go func() {
+		writerDone <- rw.LockCtx(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	readerDone := make(chan struct{})
+	go func() {
+		rw.RLock()
+		rw.RUnlock()
+		close(readerDone)
+	}()
+
+	select {
+	case <-readerDone:
+		t.Fatalf("expected reader to queue behind writer")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-writerDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+
+	select {
+	case <-readerDone:
+	case <-time.After(time.Second):
+		t.Fatalf("expected reader to proceed after writer cancelled")
+	}
+	rw.RUnlock()
+}
+
+func TestRWMutex_WriterNotStarved(t *testing.T) {
+	var rw RWMutex
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				rw.RLock()
+				time.Sleep(time.Millisecond)
+				rw.RUnlock()
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	time.Sleep(5 * time.Millisecond)
+	if err := rw.LockCtx(ctx); err != nil {
+		t.Fatalf("expected writer to acquire lock, got %v", err)
+	}
+	rw.Unlock()
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestRWMutex_Upgrade_BlocksNewReaders(t *testing.T) {
+	var rw RWMutex
+
+	rw.upgradableRLock()
+	if !rw.TryRLock() {
+		t.Fatalf("expected readers to coexist with upgradable reader")
+	}
+
+	upgraded := make(chan struct{})
+	go func() {
+		rw.upgradeLock()
+		close(upgraded)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	if rw.TryRLock() {
+		t.Fatalf("expected new readers to wait for pending upgrade")
+	}
+
+	rw.RUnlock()
+	<-upgraded
+
+	rw.upgradeUnlock()
+	if !rw.TryRLock() {
+		t.Fatalf("expected readers to enter after downgrade")
+	}
+	rw.RUnlock()
+	rw.upgradableRUnlock()
+
+	if !rw.TryLock() {
+		t.Fatalf("expected lock to be free")
+	}
+	rw.Unlock()
+}
+
+func TestMutex_TryLockFor(t *testing.T) {
+	for _, m := range []*Mutex{NewMutex(), NewMutex(WithFIFO())} {
+		m.Lock()
+
+		start := time.Now()
+		if m.TryLockFor(20 * time.Millisecond) {
+			t.Fatalf("expected TryLockFor to time out")
+		}
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Fatalf("expected TryLockFor to wait, returned after %v", elapsed)
+		}
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			m.Unlock()
+		}()
+		if !m.TryLockFor(time.Second) {
+			t.Fatalf("expected TryLockFor to succeed after unlock")
+		}
+		m.Unlock()
+	}
+}
+
+func TestMutex_FIFO(t *testing.T) {
+	m := NewMutex(WithFIFO())
+	m.Lock()
+
+	const waiters = 5
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	wg.Add(waiters)
+	for i := 0; i < waiters; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			m.Lock()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			m.Unlock()
+		}()
+		waitFor(t, func() bool {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return len(m.waiters) == i+1
+		})
+	}
+
+	m.Unlock()
+	wg.Wait()
+
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("expected FIFO order, got %v", order)
+		}
+	}
+}
+
+func TestMutex_Contention_WithCancel(t *testing.T) {
+	for _, m := range []*Mutex{NewMutex(), NewMutex(WithFIFO())} {
+		var holders atomic.Int32
+		var count int
+		critical := func() {
+			if holders.Add(1) != 1 {
+				t.Errorf("mutual exclusion violated")
+			}
+			count++
+			holders.Add(-1)
+		}
+
+		const goroutines = 8
+		var acquired atomic.Int32
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+		for i := 0; i < goroutines; i++ {
+			i := i
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					if i%2 == 0 {
+						m.Lock()
+					} else {
+						ctx, cancel := context.WithTimeout(context.Background(), time.Duration(j%3)*time.Microsecond)
+						err := m.LockCtx(ctx)
+						cancel()
+						if err != nil {
+							continue
+						}
+					}
+					acquired.Add(1)
+					critical()
+					m.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if count != int(acquired.Load()) {
+			t.Fatalf("expected %d, got %d", acquired.Load(), count)
+		}
+		// 所有等待者都已离开，锁应处于空闲状态
+		if !m.TryLock() {
+			t.Fatalf("expected mutex to be unlocked")
+		}
+		m.Unlock()
+		if s := m.state.Load(); s != 0 {
+			t.Fatalf("expected clean state, got %d", s)
+		}
+	}
+}
+
+func TestMutex_NoStarvation(t *testing.T) {
+	var m Mutex
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				m.Lock()
+				time.Sleep(100 * time.Microsecond)
+				m.Unlock()
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	if !m.TryLockFor(time.Second) {
+		t.Fatalf("expected waiter not to starve")
+	}
+	m.Unlock()
+}
+
+func TestRWMutex_Contention_FastReaders(t *testing.T) {
+	for _, policy := range []RWPolicy{RWPhaseFair, RWReaderPreferring, RWWriterPreferring} {
+		rw := NewRWMutex(WithRWPolicy(policy))
+		var readers, writers atomic.Int32
+		read := func() {
+			readers.Add(1)
+			if writers.Load() != 0 {
+				t.Errorf("policy %d: reader overlapped a writer", policy)
+			}
+			readers.Add(-1)
+			rw.RUnlock()
+		}
+
+		const goroutines = 8
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+		for i := 0; i < goroutines; i++ {
+			i := i
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					switch {
+					case i == 0:
+						rw.Lock()
+						if writers.Add(1) != 1 || readers.Load() != 0 {
+							t.Errorf("policy %d: writer not exclusive", policy)
+						}
+						writers.Add(-1)
+						rw.Unlock()
+					case i%2 == 1:
+						ctx, cancel := context.WithTimeout(context.Background(), time.Duration(j%3)*time.Microsecond)
+						err := rw.RLockCtx(ctx)
+						cancel()
+						if err == nil {
+							read()
+						}
+					default:
+						rw.RLock()
+						read()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		if s := rw.state.Load(); s != 0 {
+			t.Fatalf("policy %d: expected clean state, got %d", policy, s)
+		}
+	}
+}
+
+func TestMutex_SyncCond(t *testing.T) {
+	var m Mutex
+	cond := sync.NewCond(&m)
+
+	ready := false
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Lock()
+		ready = true
+		m.Unlock()
+		cond.Signal()
+	}()
+
+	m.Lock()
+	for !ready {
+		cond.Wait()
+	}
+	m.Unlock()
+}
+
+func TestRWMutex_TryLockFor(t *testing.T) {
+	rw := NewRWMutex()
+	rw.RLock()
+
+	if !rw.TryRLockFor(10 * time.Millisecond) {
+		t.Fatalf("expected TryRLockFor to succeed while reading")
+	}
+	rw.RUnlock()
+
+	if rw.TryLockFor(10 * time.Millisecond) {
+		t.Fatalf("expected TryLockFor to time out while reading")
+	}
+	rw.RUnlock()
+
+	if !rw.TryLockFor(10 * time.Millisecond) {
+		t.Fatalf("expected TryLockFor to succeed")
+	}
+	rw.Unlock()
+}
+
+func TestRWMutex_RLocker(t *testing.T) {
+	var rw RWMutex
+	l := rw.RLocker()
+
+	l.Lock()
+	if rw.TryLock() {
+		t.Fatalf("expected writers to be excluded by RLocker")
+	}
+	l.Unlock()
+
+	if !rw.TryLock() {
+		t.Fatalf("expected lock to be free")
+	}
+	rw.Unlock()
+}
+
+func TestRWMutex_Policy_NewReaders(t *testing.T) {
+	tests := []struct {
+		policy RWPolicy
+		admit  bool
+	}{
+		{RWPhaseFair, false},
+		{RWReaderPreferring, true},
+		{RWWriterPreferring, false},
+	}
+
+	for _, tt := range tests {
+		rw := NewRWMutex(WithRWPolicy(tt.policy))
+		rw.RLock()
+
+		done := make(chan struct{})
+		go func() {
+			rw.Lock()
+			rw.Unlock()
+			close(done)
+		}()
+		waitFor(t, func() bool {
+			rw.mu.Lock()
+			defer rw.mu.Unlock()
+			return rw.writers == 1
+		})
+
+		if got := rw.TryRLock(); got != tt.admit {
+			t.Fatalf("policy %d: expected TryRLock=%v with a waiting writer", tt.policy, tt.admit)
+		} else if got {
+			rw.RUnlock()
+		}
+
+		rw.RUnlock()
+		<-done
+	}
+}
+
+func TestRWMutex_Policy_Dispatch(t *testing.T) {
+	tests := []struct {
+		policy      RWPolicy
+		writerFirst bool
+	}{
+		{RWPhaseFair, false},
+		{RWReaderPreferring, false},
+		{RWWriterPreferring, true},
+	}
+
+	for _, tt := range tests {
+		rw := NewRWMutex(WithRWPolicy(tt.policy))
+		rw.Lock()
+
+		// 写锁被持有时，依次排入一个读者和一个写者
+		order := make(chan string, 2)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rw.RLock()
+			order <- "reader"
+			time.Sleep(10 * time.Millisecond)
+			rw.RUnlock()
+		}()
+		waitFor(t, func() bool {
+			rw.mu.Lock()
+			defer rw.mu.Unlock()
+			return len(rw.queue) == 1
+		})
+		go func() {
+			defer wg.Done()
+			rw.Lock()
+			order <- "writer"
+			time.Sleep(10 * time.Millisecond)
+			rw.Unlock()
+		}()
+		waitFor(t, func() bool {
+			rw.mu.Lock()
+			defer rw.mu.Unlock()
+			return len(rw.queue) == 2
+		})
+
+		rw.Unlock()
+		wg.Wait()
+
+		want := "reader"
+		if tt.writerFirst {
+			want = "writer"
+		}
+		if got := <-order; got != want {
+			t.Fatalf("policy %d: expected %s first, got %s", tt.policy, want, got)
+		}
+	}
+}
+
+func BenchmarkRWMutex_RLock(b *testing.B) {
+	var rw RWMutex
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rw.RLock()
+			rw.RUnlock()
+		}
+	})
+}
+
+func BenchmarkRWMutex_RLock_StdRWMutex(b *testing.B) {
+	var rw sync.RWMutex
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			rw.RLock()
+			rw.RUnlock()
+		}
+	})
+}
+
+func BenchmarkRWMutexValue_RLock(b *testing.B) {
+	mv := NewRWMutexValue(42)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mv.RLock(func(v int) {})
+		}
+	})
+}
+
+func BenchmarkMutex_Lock(b *testing.B) {
+	var m Mutex
+	for i := 0; i < b.N; i++ {
+		m.Lock()
+		m.Unlock()
+	}
+}
+
+func BenchmarkMutex_Lock_StdMutex(b *testing.B) {
+	var m sync.Mutex
+	for i := 0; i < b.N; i++ {
+		m.Lock()
+		m.Unlock()
+	}
+}
+
+func BenchmarkMutex_Lock_Parallel(b *testing.B) {
+	var m Mutex
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Lock()
+			m.Unlock()
+		}
+	})
+}
+
+func BenchmarkMutex_Lock_Parallel_StdMutex(b *testing.B) {
+	var m sync.Mutex
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Lock()
+			m.Unlock()
+		}
+	})
+}
+
+func BenchmarkMutexValue_Lock_Parallel(b *testing.B) {
+	mv := NewMutexValue(0)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mv.Lock(func(v *int) { *v++ })
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package tsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// LockOrderEdge 记录一次“持有 A 时获取 B”的调用栈。
+type LockOrderEdge struct {
+	HeldStack    string // 获取 A 时的栈
+	AcquireStack string // 持有 A 获取 B 时的栈
+}
+
+// LockOrderViolation 描述一个锁获取顺序环，即潜在的死锁：
+// Current 是本次获取，Cycle 是此前记录的、与之构成环的获取顺序。
+type LockOrderViolation struct {
+	Current LockOrderEdge
+	Cycle   []LockOrderEdge
+}
+
+func (v LockOrderViolation) String() string {
+	var b strings.Builder
+	b.WriteString("tsync: potential deadlock, inconsistent lock order\n")
+	writeEdge := func(title string, e LockOrderEdge) {
+		fmt.Fprintf(&b, "\n%s\nlock held since:\n%s\nacquiring another lock at:\n%s\n", title, e.HeldStack, e.AcquireStack)
+	}
+	writeEdge("=== current acquisition ===", v.Current)
+	for i, e := range v.Cycle {
+		writeEdge(fmt.Sprintf("=== previous acquisition #%d ===", i+1), e)
+	}
+	return b.String()
+}
+
+var lockOrderHandler = struct {
+	sync.Mutex
+	fn func(LockOrderViolation)
+}{}
+
+// SetLockOrderHandler 设置发现锁顺序环时的处理函数，nil 表示默认行为（panic）。
+// 检测仅在 tsync_debug 构建下进行，覆盖 MutexValue、RWMutexValue 和 Cond 使用的锁。
+func SetLockOrderHandler(fn func(v LockOrderViolation)) {
+	lockOrderHandler.Lock()
+	lockOrderHandler.fn = fn
+	lockOrderHandler.Unlock()
+}
+
+func reportLockOrder(v LockOrderViolation) {
+	lockOrderHandler.Lock()
+	fn := lockOrderHandler.fn
+	lockOrderHandler.Unlock()
+
+	if fn == nil {
+		panic(v.String())
+	}
+	fn(v)
+}
+
+// maxLockOrderEdges 限制顺序图保留的边数。锁的编号不会复用，为每个请求或测试创建的值
+// 会不断加入新边，不加限制时图和每次加边时的环检测都会无限增长。
+const maxLockOrderEdges = 4096
+
+var lockOrder = newLockGraph(maxLockOrderEdges)
+
+// lockGraph 记录各 goroutine 当前持有的锁，以及“持有 A 时获取 B”的全局有向图。
+// 在图中加入会形成环的边即说明存在两个 goroutine 以相反顺序获取同一组锁。
+// 边数超过 limit 时淘汰最早加入的边，因此相隔很久的反序获取可能不再被发现。
+type lockGraph struct {
+	mu    sync.Mutex
+	edges map[uint64]map[uint64]LockOrderEdge
+	order []lockEdgeKey // 按加入顺序排列，用于淘汰最早的边
+	limit int
+	held  map[int64][]heldLock
+}
+
+type lockEdgeKey struct {
+	from, to uint64
+}
+
+func newLockGraph(limit int) *lockGraph {
+	return &lockGraph{
+		edges: make(map[uint64]map[uint64]LockOrderEdge),
+		limit: limit,
+		held:  make(map[int64][]heldLock),
+	}
+}
+
+type heldLock struct {
+	lock  uint64
+	stack string
+}
+
+// before 在可能阻塞的获取之前调用，因此即使真的会死锁也能先报告。
+// 当前 goroutine 已持有同一把锁时，除非获取带有截止时间（如 TryLockFor，
+// 只会超时失败），否则直接 panic：即使 ctx 可以取消，也几乎总是自死锁。
+func (g *lockGraph) before(lock uint64, hasDeadline bool) {
+	id := goid()
+	stack := callerStack()
+
+	g.mu.Lock()
+	var violation *LockOrderViolation
+	for _, h := range g.held[id] {
+		if h.lock == lock {
+			if hasDeadline {
+				continue
+			}
+			g.mu.Unlock()
+			panic(fmt.Sprintf("tsync: reentrant lock, the lock is already held by this goroutine "+
+				"(use ReentrantMutexValue if reentry is intended)\n\nlock held since:\n%s\nacquiring again at:\n%s", h.stack, stack))
+		}
+		if _, ok := g.edges[h.lock][lock]; ok {
+			continue
+		}
+		current := LockOrderEdge{HeldStack: h.stack, AcquireStack: stack}
+		if cycle := g.path(lock, h.lock); cycle != nil {
+			violation = &LockOrderViolation{Current: current, Cycle: cycle}
+			break
+		}
+		g.addEdge(h.lock, lock, current)
+	}
+	g.mu.Unlock()
+
+	if violation != nil {
+		reportLockOrder(*violation)
+	}
+}
+
+func hasDeadline(ctx context.Context) bool {
+	_, ok := ctx.Deadline()
+	return ok
+}
+
+func (g *lockGraph) acquired(lock uint64) {
+	id := goid()
+	stack := callerStack()
+
+	g.mu.Lock()
+	g.held[id] = append(g.held[id], heldLock{lock: lock, stack: stack})
+	g.mu.Unlock()
+}
+
+// released 优先从当前 goroutine 移除；锁也可能由其他 goroutine 释放。
+func (g *lockGraph) released(lock uint64) {
+	id := goid()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.remove(id, lock) {
+		return
+	}
+	for other := range g.held {
+		if g.remove(other, lock) {
+			return
+		}
+	}
+}
+
+func (g *lockGraph) remove(id int64, lock uint64) bool {
+	held := g.held[id]
+	for i := len(held) - 1; i >= 0; i-- {
+		if held[i].lock == lock {
+			held = append(held[:i], held[i+1:]...)
+			if len(held) == 0 {
+				delete(g.held, id)
+			} else {
+				g.held[id] = held
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// addEdge 需持有 mu。
+func (g *lockGraph) addEdge(from, to uint64, e LockOrderEdge) {
+	if g.edges[from] == nil {
+		g.edges[from] = make(map[uint64]LockOrderEdge)
+	}
+	g.edges[from][to] = e
+	g.order = append(g.order, lockEdgeKey{from: from, to: to})
+
+	for len(g.order) > g.limit {
+		oldest := g.order[0]
+		g.order[0] = lockEdgeKey{}
+		g.order = g.order[1:]
+		delete(g.edges[oldest.from], oldest.to)
+		if len(g.edges[oldest.from]) == 0 {
+			delete(g.edges, oldest.from)
+		}
+	}
+}
+
+// path 返回图中从 from 到 to 的一条路径上的边，不存在时返回 nil。
+func (g *lockGraph) path(from, to uint64) []LockOrderEdge {
+	visited := make(map[uint64]bool)
+	var dfs func(n uint64) []LockOrderEdge
+	dfs = func(n uint64) []LockOrderEdge {
+		visited[n] = true
+		for next, e := range g.edges[n] {
+			if next == to {
+				return []LockOrderEdge{e}
+			}
+			if visited[next] {
+				continue
+			}
+			if rest := dfs(next); rest != nil {
+				return append([]LockOrderEdge{e}, rest...)
+			}
+		}
+		return nil
+	}
+	return dfs(from)
+}
@@ -0,0 +1,160 @@
+//go:build tsync_debug
+
+package tsync
+
+import (
+	"strings"
+	"testing"
+)
+
+func captureLockOrder(t *testing.T) *[]LockOrderViolation {
+	var got []LockOrderViolation
+	SetLockOrderHandler(func(v LockOrderViolation) {
+		got = append(got, v)
+	})
+	t.Cleanup(func() {
+		SetLockOrderHandler(nil)
+	})
+	return &got
+}
+
+func lockAB(a, b *MutexValue[int]) {
+	a.Lock(func(*int) {
+		b.Lock(func(*int) {})
+	})
+}
+
+func lockBA(a, b *MutexValue[int]) {
+	b.Lock(func(*int) {
+		a.Lock(func(*int) {})
+	})
+}
+
+func TestLockOrder_Inversion(t *testing.T) {
+	got := captureLockOrder(t)
+
+	a := NewMutexValue(0)
+	b := NewMutexValue(0)
+
+	lockAB(a, b)
+	if len(*got) != 0 {
+		t.Fatalf("unexpected violation")
+	}
+
+	// 不同 goroutine 以相反顺序加锁，即使没有真正死锁也应报告
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lockBA(a, b)
+	}()
+	<-done
+
+	if len(*got) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(*got))
+	}
+	v := (*got)[0]
+	if !strings.Contains(v.Current.AcquireStack, "lockBA") {
+		t.Fatalf("expected current stack to contain lockBA:\n%s", v.Current.AcquireStack)
+	}
+	if len(v.Cycle) != 1 || !strings.Contains(v.Cycle[0].AcquireStack, "lockAB") {
+		t.Fatalf("expected previous stack to contain lockAB:\n%+v", v.Cycle)
+	}
+}
+
+func TestLockOrder_Consistent(t *testing.T) {
+	got := captureLockOrder(t)
+
+	a := NewMutexValue(0)
+	b := NewRWMutexValue(0)
+
+	for i := 0; i < 3; i++ {
+		a.Lock(func(*int) {
+			b.RLock(func(int) {})
+		})
+	}
+
+	if len(*got) != 0 {
+		t.Fatalf("unexpected violations %d", len(*got))
+	}
+}
+
+func TestLockOrder_ThreeLockCycle(t *testing.T) {
+	got := captureLockOrder(t)
+
+	a := NewMutexValue(0)
+	b := NewMutexValue(0)
+	c := NewRWMutexValue(0)
+
+	a.Lock(func(*int) { b.Lock(func(*int) {}) })
+	b.Lock(func(*int) { c.Lock(func(*int) {}) })
+	c.RLock(func(int) { a.Lock(func(*int) {}) })
+
+	if len(*got) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(*got))
+	}
+	if n := len((*got)[0].Cycle); n != 2 {
+		t.Fatalf("expected cycle of 2 previous edges, got %d", n)
+	}
+}
+
+func TestLockOrder_Cond(t *testing.T) {
+	got := captureLockOrder(t)
+
+	c := NewCond()
+	mv := NewMutexValue(true)
+
+	c.WaitUntil(func() bool {
+		return mv.Load()
+	})
+	mv.Lock(func(*bool) {
+		c.Signal()
+	})
+
+	if len(*got) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(*got))
+	}
+}
+
+func TestLockOrder_DefaultPanics(t *testing.T) {
+	a := NewMutexValue(0)
+	b := NewMutexValue(0)
+	lockAB(a, b)
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(r.(string), "potential deadlock") {
+			t.Fatalf("expected potential deadlock panic, got %v", r)
+		}
+	}()
+
+	lockBA(a, b)
+}
+
+func TestLockOrder_EdgesBounded(t *testing.T) {
+	g := newLockGraph(4)
+
+	// 每对新锁都加入一条边
+	for i := uint64(0); i < 10; i++ {
+		a, b := 2*i+1, 2*i+2
+		g.before(a, false)
+		g.acquired(a)
+		g.before(b, false)
+		g.acquired(b)
+		g.released(b)
+		g.released(a)
+	}
+
+	edges := 0
+	for _, to := range g.edges {
+		edges += len(to)
+	}
+	if edges != 4 || len(g.order) != 4 {
+		t.Fatalf("expected 4 edges, got %d (order %d)", edges, len(g.order))
+	}
+	if _, ok := g.edges[1]; ok {
+		t.Fatalf("expected oldest edge to be evicted")
+	}
+	if _, ok := g.edges[19][20]; !ok {
+		t.Fatalf("expected newest edge to be kept")
+	}
+}
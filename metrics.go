@@ -0,0 +1,278 @@
+package tsync
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics 接收带锁的值（MutexValue、RWMutexValue 等）的锁统计事件，
+// 可以桥接到 Prometheus、expvar 等。name 来自 WithName。
+// 方法会被并发调用，并在获取、释放锁的路径上同步执行，应尽量轻量。
+type Metrics interface {
+	// ObserveWait 在每次获得锁时调用，d 为等待时间，未阻塞时为 0。
+	// 调用次数即获取次数。
+	ObserveWait(name string, d time.Duration)
+	// ObserveHold 在每次释放锁时调用，d 为持有时间。
+	ObserveHold(name string, d time.Duration)
+	// Waiters 在阻塞等待的 goroutine 数量变化时调用，delta 为 +1 或 -1。
+	Waiters(name string, delta int)
+}
+
+// HoldInfo 描述一次持有时间超过 WithHoldWarning 阈值的锁持有。
+type HoldInfo struct {
+	Name  string        // WithName 指定的锁名
+	Held  time.Duration // 告警时已持有的时间
+	Stack string        // 告警时持有者 goroutine 的调用栈
+}
+
+// lockObserver 把一把锁的事件转发给 Metrics 和 WithHoldWarning 的回调。
+// nil 表示都未开启，锁只做一次 nil 判断。
+type lockObserver struct {
+	name      string
+	metrics   Metrics
+	warnAfter time.Duration
+	warn      func(HoldInfo)
+
+	// 写锁和可升级读锁只有一个持有者，由锁本身保护
+	write      lockHold
+	upgradable lockHold
+
+	// 读锁可能有多个持有者。开启持有告警时按 goroutine 配对；
+	// 否则按先进先出配对，总持有时间准确，单次持有时间是近似值。
+	mu    sync.Mutex
+	reads []lockHold
+}
+
+type lockHold struct {
+	since time.Time
+	goid  int64
+	timer *time.Timer
+}
+
+func newLockObserver(o *options) *lockObserver {
+	if o.metrics == nil && o.warn == nil {
+		return nil
+	}
+	return &lockObserver{
+		name:      o.name,
+		metrics:   o.metrics,
+		warnAfter: o.warnAfter,
+		warn:      o.warn,
+	}
+}
+
+func (l *lockObserver) waiting(delta int) {
+	if l.metrics != nil {
+		l.metrics.Waiters(l.name, delta)
+	}
+}
+
+// acquired 在获得锁之后调用，start 为开始等待的时间，零值表示未阻塞。
+func (l *lockObserver) acquired(kind lockKind, start time.Time) {
+	now := time.Now()
+	if l.metrics != nil {
+		var wait time.Duration
+		if !start.IsZero() {
+			wait = now.Sub(start)
+		}
+		l.metrics.ObserveWait(l.name, wait)
+	}
+
+	h := lockHold{since: now}
+	if l.warn != nil {
+		h.goid = goid()
+		id := h.goid
+		h.timer = time.AfterFunc(l.warnAfter, func() {
+			l.warn(HoldInfo{
+				Name:  l.name,
+				Held:  time.Since(now),
+				Stack: goroutineStack(id),
+			})
+		})
+	}
+
+	switch kind {
+	case lockRead:
+		l.mu.Lock()
+		l.reads = append(l.reads, h)
+		l.mu.Unlock()
+	case lockUpgradable:
+		l.upgradable = h
+	default:
+		l.write = h
+	}
+}
+
+func (l *lockObserver) released(kind lockKind) {
+	var h lockHold
+	switch kind {
+	case lockRead:
+		l.mu.Lock()
+		h = l.popRead()
+		l.mu.Unlock()
+	case lockUpgradable:
+		h = l.upgradable
+	default:
+		h = l.write
+	}
+
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	if l.metrics != nil && !h.since.IsZero() {
+		l.metrics.ObserveHold(l.name, time.Since(h.since))
+	}
+}
+
+// downgraded 在写锁降级为读锁时调用，持有时间从获得写锁时起算。
+func (l *lockObserver) downgraded() {
+	l.mu.Lock()
+	l.reads = append(l.reads, l.write)
+	l.mu.Unlock()
+}
+
+// popRead 需持有 mu。读锁也可能由其他 goroutine 释放，找不到时取最早的记录。
+func (l *lockObserver) popRead() lockHold {
+	if len(l.reads) == 0 {
+		return lockHold{}
+	}
+	i := 0
+	if l.warn != nil {
+		id := goid()
+		for j := len(l.reads) - 1; j >= 0; j-- {
+			if l.reads[j].goid == id {
+				i = j
+				break
+			}
+		}
+	}
+	h := l.reads[i]
+	l.reads = append(l.reads[:i], l.reads[i+1:]...)
+	return h
+}
+
+// DefaultDurationBuckets 是 LockMetrics 默认的直方图上界。
+var DefaultDurationBuckets = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// DurationHistogram 是耗时分布的快照。
+type DurationHistogram struct {
+	Count  uint64
+	Sum    time.Duration
+	Max    time.Duration
+	Bounds []time.Duration // 各个桶的上界（含）
+	Counts []uint64        // 比 Bounds 多一个元素，最后一个统计超过所有上界的观测
+}
+
+func (h *DurationHistogram) observe(d time.Duration) {
+	h.Count++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+	h.Counts[sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })]++
+}
+
+func (h DurationHistogram) clone() DurationHistogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// LockStats 是一把命名锁的统计快照。
+type LockStats struct {
+	Acquisitions uint64 // 获得锁的次数
+	Waiters      int64  // 当前阻塞等待的 goroutine 数
+	Wait         DurationHistogram
+	Hold         DurationHistogram
+}
+
+// LockMetrics 是 Metrics 的简单内存实现，按锁名汇总统计，
+// 适合测试、调试或定期导出到 expvar。零值不可用，请使用 NewLockMetrics。
+type LockMetrics struct {
+	bounds []time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*LockStats
+}
+
+// NewLockMetrics 使用给定的直方图上界（升序）创建 LockMetrics，
+// 未指定时使用 DefaultDurationBuckets。
+func NewLockMetrics(buckets ...time.Duration) *LockMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	return &LockMetrics{
+		bounds: append([]time.Duration(nil), buckets...),
+		locks:  make(map[string]*LockStats),
+	}
+}
+
+func (m *LockMetrics) ObserveWait(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.lock(name)
+	s.Acquisitions++
+	s.Wait.observe(d)
+}
+
+func (m *LockMetrics) ObserveHold(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lock(name).Hold.observe(d)
+}
+
+func (m *LockMetrics) Waiters(name string, delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lock(name).Waiters += int64(delta)
+}
+
+// Stats 返回指定锁的统计快照，没有记录时返回零值。
+func (m *LockMetrics) Stats(name string) LockStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.locks[name]
+	if !ok {
+		return LockStats{}
+	}
+	return s.clone()
+}
+
+// Snapshot 返回所有锁的统计快照。
+func (m *LockMetrics) Snapshot() map[string]LockStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]LockStats, len(m.locks))
+	for name, s := range m.locks {
+		out[name] = s.clone()
+	}
+	return out
+}
+
+func (m *LockMetrics) lock(name string) *LockStats {
+	s, ok := m.locks[name]
+	if !ok {
+		s = &LockStats{
+			Wait: DurationHistogram{Bounds: m.bounds, Counts: make([]uint64, len(m.bounds)+1)},
+			Hold: DurationHistogram{Bounds: m.bounds, Counts: make([]uint64, len(m.bounds)+1)},
+		}
+		m.locks[name] = s
+	}
+	return s
+}
+
+func (s *LockStats) clone() LockStats {
+	c := *s
+	c.Wait = s.Wait.clone()
+	c.Hold = s.Hold.clone()
+	return c
+}
@@ -0,0 +1,232 @@
+package tsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockMetrics_MutexValue(t *testing.T) {
+	metrics := NewLockMetrics()
+	mv := NewMutexValue(0, WithName("counter"), WithMetrics(metrics))
+
+	mv.Store(1)
+	mv.Lock(func(v *int) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	mv.TryLock(func(v *int) {})
+
+	s := metrics.Stats("counter")
+	if s.Acquisitions != 3 || s.Wait.Count != 3 || s.Hold.Count != 3 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.Hold.Max < 20*time.Millisecond {
+		t.Fatalf("expected hold time >= 20ms, got %v", s.Hold.Max)
+	}
+	if s.Waiters != 0 {
+		t.Fatalf("expected no waiters, got %d", s.Waiters)
+	}
+}
+
+func TestLockMetrics_Waiters(t *testing.T) {
+	metrics := NewLockMetrics()
+	mv := NewRWMutexValue(0, WithName("config"), WithMetrics(metrics))
+
+	release := make(chan struct{})
+	locked := make(chan struct{})
+	go mv.Lock(func(v *int) {
+		close(locked)
+		<-release
+	})
+	<-locked
+
+	done := make(chan struct{})
+	go func() {
+		mv.RLock(func(v int) {})
+		close(done)
+	}()
+
+	waitFor(t, func() bool { return metrics.Stats("config").Waiters == 1 })
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	<-done
+
+	s := metrics.Stats("config")
+	if s.Waiters != 0 {
+		t.Fatalf("expected no waiters, got %d", s.Waiters)
+	}
+	if s.Acquisitions != 2 || s.Hold.Count != 2 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.Wait.Max < 10*time.Millisecond {
+		t.Fatalf("expected wait time >= 10ms, got %v", s.Wait.Max)
+	}
+}
+
+func TestLockMetrics_Cancelled(t *testing.T) {
+	metrics := NewLockMetrics()
+	mv := NewMutexValue(0, WithName("m"), WithMetrics(metrics))
+
+	mv.Lock(func(v *int) {
+		done := make(chan error)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			done <- mv.LockCtx(ctx, func(v *int) {})
+		}()
+		if err := <-done; err == nil {
+			t.Errorf("expected LockCtx to time out")
+		}
+	})
+
+	s := metrics.Stats("m")
+	if s.Acquisitions != 1 || s.Waiters != 0 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
+
+func TestLockMetrics_ConcurrentReaders(t *testing.T) {
+	metrics := NewLockMetrics()
+	mv := NewRWMutexValue(0, WithName("rw"), WithMetrics(metrics))
+
+	const readers = 5
+	done := make(chan struct{})
+	for i := 0; i < readers; i++ {
+		go func() {
+			mv.RLock(func(v int) {
+				time.Sleep(10 * time.Millisecond)
+			})
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < readers; i++ {
+		<-done
+	}
+
+	s := metrics.Stats("rw")
+	if s.Acquisitions != readers || s.Hold.Count != readers {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.Hold.Sum < readers*10*time.Millisecond {
+		t.Fatalf("expected total hold >= %v, got %v", readers*10*time.Millisecond, s.Hold.Sum)
+	}
+}
+
+func TestDurationHistogram_Buckets(t *testing.T) {
+	metrics := NewLockMetrics(time.Millisecond, time.Second)
+	metrics.ObserveWait("h", 0)
+	metrics.ObserveWait("h", time.Millisecond)
+	metrics.ObserveWait("h", 10*time.Millisecond)
+	metrics.ObserveWait("h", time.Minute)
+
+	s := metrics.Snapshot()["h"]
+	want := []uint64{2, 1, 1}
+	for i, n := range want {
+		if s.Wait.Counts[i] != n {
+			t.Fatalf("expected counts %v, got %v", want, s.Wait.Counts)
+		}
+	}
+	if s.Wait.Max != time.Minute {
+		t.Fatalf("expected max 1m, got %v", s.Wait.Max)
+	}
+}
+
+func TestHoldWarning_FiresWhileHeld(t *testing.T) {
+	warned := make(chan HoldInfo, 1)
+	mv := NewMutexValue(0, WithName("slow"), WithHoldWarning(10*time.Millisecond, func(info HoldInfo) {
+		warned <- info
+	}))
+
+	mv.Lock(func(v *int) {
+		select {
+		case info := <-warned:
+			if info.Name != "slow" || info.Held < 10*time.Millisecond {
+				t.Errorf("unexpected info %+v", info)
+			}
+			if !strings.Contains(info.Stack, "TestHoldWarning_FiresWhileHeld") {
+				t.Errorf("expected holder stack, got:\n%s", info.Stack)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected warning while the lock is held")
+		}
+	})
+}
+
+func TestHoldWarning_FastCallback(t *testing.T) {
+	warned := make(chan HoldInfo, 1)
+	mv := NewRWMutexValue(0, WithHoldWarning(20*time.Millisecond, func(info HoldInfo) {
+		warned <- info
+	}))
+
+	for i := 0; i < 10; i++ {
+		mv.RLock(func(v int) {})
+		mv.Store(i)
+	}
+
+	select {
+	case info := <-warned:
+		t.Fatalf("unexpected warning %+v", info)
+	case <-time.After(40 * time.Millisecond):
+	}
+}
+
+func TestHoldWarning_Readers(t *testing.T) {
+	warned := make(chan HoldInfo, 2)
+	mv := NewRWMutexValue(0, WithName("rw"), WithHoldWarning(10*time.Millisecond, func(info HoldInfo) {
+		warned <- info
+	}))
+
+	slowIn := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mv.RLock(func(v int) {
+			close(slowIn)
+			<-release
+		})
+	}()
+	<-slowIn
+
+	// 快速读者先释放，不应取消慢读者的告警
+	mv.RLock(func(v int) {})
+
+	select {
+	case info := <-warned:
+		if !strings.Contains(info.Stack, "TestHoldWarning_Readers.func") {
+			t.Errorf("expected slow reader stack, got:\n%s", info.Stack)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected warning for slow reader")
+	}
+
+	close(release)
+	<-done
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func BenchmarkMutexValue_Lock(b *testing.B) {
+	mv := NewMutexValue(0)
+	for i := 0; i < b.N; i++ {
+		mv.Lock(func(v *int) { *v++ })
+	}
+}
+
+func BenchmarkMutexValue_Lock_Metrics(b *testing.B) {
+	mv := NewMutexValue(0, WithName("bench"), WithMetrics(NewLockMetrics()))
+	for i := 0; i < b.N; i++ {
+		mv.Lock(func(v *int) { *v++ })
+	}
+}
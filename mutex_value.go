@@ -1,24 +1,130 @@
 package tsync
 
-import "sync"
+import "context"
 
 type MutexValue[T any] struct {
-	mu sync.Mutex
-	v  T
+	mu      Mutex
+	v       T
+	clone   func(T) T
+	changes changeHooks[T]
 }
 
-func NewMutexValue[T any](v T) *MutexValue[T] {
-	return &MutexValue[T]{v: v}
+func NewMutexValue[T any](v T, opts ...OptionOf[T]) *MutexValue[T] {
+	o := newOptions(opts)
+	clone := cloneOption[T](o)
+	return &MutexValue[T]{
+		mu:      newMutex(o),
+		v:       v,
+		clone:   clone,
+		changes: changeHooks[T]{clone: clone, equal: equalOption[T](o)},
+	}
 }
 
 func (m *MutexValue[T]) Lock(fn func(v *T)) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	defer m.unlock(m.changes.snapshot(&m.v))
 	fn(&m.v)
 }
 
+// Load 返回当前值的副本；配置了拷贝函数时返回深拷贝。
 func (m *MutexValue[T]) Load() T {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.clone != nil {
+		return m.clone(m.v)
+	}
+	return m.v
+}
+
+func (m *MutexValue[T]) Store(v T) {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	m.v = v
+}
+
+func (m *MutexValue[T]) Swap(v T) (old T) {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	old, m.v = m.v, v
+	return old
+}
+
+// Update 以 fn 的返回值替换当前值并返回新值。
+func (m *MutexValue[T]) Update(fn func(old T) T) T {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	m.v = fn(m.v)
 	return m.v
 }
+
+// TryLock 仅在能立即获得锁时执行 fn，并报告是否执行。
+func (m *MutexValue[T]) TryLock(fn func(v *T)) bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	defer m.unlock(m.changes.snapshot(&m.v))
+	fn(&m.v)
+	return true
+}
+
+// LockCtx 与 Lock 相同，但在获得锁之前 ctx 结束时放弃并返回 ctx.Err()。
+func (m *MutexValue[T]) LockCtx(ctx context.Context, fn func(v *T)) error {
+	if err := m.mu.LockCtx(ctx); err != nil {
+		return err
+	}
+	defer m.unlock(m.changes.snapshot(&m.v))
+	fn(&m.v)
+	return nil
+}
+
+// LockErr 与 Lock 相同，但把 fn 返回的错误传递给调用方。
+func (m *MutexValue[T]) LockErr(fn func(v *T) error) error {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	return fn(&m.v)
+}
+
+// Transact 在当前值的副本上执行 fn，仅当 fn 返回 nil 时提交副本；
+// fn 返回错误或 panic 时原值保持不变。副本默认是浅拷贝，若 T 包含 slice、map
+// 等引用类型，应通过 WithClone 或实现 Cloner[T] 提供深拷贝函数。
+func (m *MutexValue[T]) Transact(fn func(v *T) error) error {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+
+	v := m.v
+	if m.clone != nil {
+		v = m.clone(v)
+	}
+	if err := fn(&v); err != nil {
+		return err
+	}
+	m.v = v
+	return nil
+}
+
+// OnChange 注册在值被修改后调用的 fn，old 和 new 分别是修改前后的值。
+// 只有写入后的值与原值不相等（见 WithEqual）时才调用；fn 在释放锁之后、
+// 按修改发生的顺序执行，可能运行在其他修改者的 goroutine 中。
+// T 包含 slice、map 等引用类型时应配置 WithClone，否则原地修改无法被识别。
+// unsubscribe 返回后不会再开始新的调用。
+func (m *MutexValue[T]) OnChange(fn func(old, new T)) (unsubscribe func()) {
+	return m.changes.add(fn)
+}
+
+// unlock 释放锁；old 非 nil 时记录本次修改，并在锁外通知 OnChange 的回调。
+func (m *MutexValue[T]) unlock(old *T) {
+	if old == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.changes.record(*old, m.v)
+	m.mu.Unlock()
+	m.changes.notify()
+}
+
+// WithLock 在 m 的锁内执行 fn 并返回其结果。
+func WithLock[T, R any](m *MutexValue[T], fn func(v *T) R) R {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	return fn(&m.v)
+}
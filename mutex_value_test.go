@@ -1,9 +1,12 @@
 package tsync
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestMutexValue_Load(t *testing.T) {
@@ -102,3 +105,304 @@ func TestMutexValue_GenericType(t *testing.T) {
 		t.Fatalf("unexpected value: %+v", v)
 	}
 }
+
+func TestMutexValue_LockErr(t *testing.T) {
+	mv := NewMutexValue(1)
+	errFail := errors.New("fail")
+
+	err := mv.LockErr(func(v *int) error {
+		*v = 2
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("expected %v, got %v", errFail, err)
+	}
+
+	if err := mv.LockErr(func(v *int) error { return nil }); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	mv := NewMutexValue([]int{1, 2, 3})
+
+	n := WithLock(mv, func(v *[]int) int {
+		*v = append(*v, 4)
+		return len(*v)
+	})
+
+	if n != 4 {
+		t.Fatalf("expected 4, got %d", n)
+	}
+}
+
+func TestMutexValue_Transact(t *testing.T) {
+	type account struct {
+		Balance int
+		History []int
+	}
+
+	clone := func(a account) account {
+		a.History = append([]int(nil), a.History...)
+		return a
+	}
+
+	mv := NewMutexValue(account{Balance: 10}, WithClone(clone))
+	errInsufficient := errors.New("insufficient funds")
+
+	withdraw := func(n int) error {
+		return mv.Transact(func(a *account) error {
+			a.History = append(a.History, -n)
+			a.Balance -= n
+			if a.Balance < 0 {
+				return errInsufficient
+			}
+			return nil
+		})
+	}
+
+	if err := withdraw(4); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := withdraw(20); !errors.Is(err, errInsufficient) {
+		t.Fatalf("expected %v, got %v", errInsufficient, err)
+	}
+
+	a := mv.Load()
+	if a.Balance != 6 || len(a.History) != 1 {
+		t.Fatalf("expected rollback, got %+v", a)
+	}
+}
+
+func TestMutexValue_Transact_Panic(t *testing.T) {
+	mv := NewMutexValue(1)
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatalf("expected panic")
+			}
+		}()
+		_ = mv.Transact(func(v *int) error {
+			*v = 2
+			panic("boom")
+		})
+	}()
+
+	if v := mv.Load(); v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+
+	// 锁已释放
+	mv.Lock(func(v *int) {
+		*v = 3
+	})
+}
+
+func TestMutexValue_TryLock(t *testing.T) {
+	mv := NewMutexValue(0)
+
+	mv.Lock(func(v *int) {
+		if mv.TryLock(func(v *int) { *v = 1 }) {
+			t.Fatalf("expected TryLock to fail while locked")
+		}
+	})
+
+	if !mv.TryLock(func(v *int) { *v = 2 }) {
+		t.Fatalf("expected TryLock to succeed")
+	}
+	if v := mv.Load(); v != 2 {
+		t.Fatalf("expected 2, got %d", v)
+	}
+}
+
+func TestMutexValue_LockCtx(t *testing.T) {
+	mv := NewMutexValue(0)
+
+	release := make(chan struct{})
+	locked := make(chan struct{})
+	go mv.Lock(func(v *int) {
+		close(locked)
+		<-release
+	})
+	<-locked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := mv.LockCtx(ctx, func(v *int) {
+		t.Fatalf("fn should not run")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	close(release)
+
+	if err := mv.LockCtx(context.Background(), func(v *int) { *v = 3 }); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if v := mv.Load(); v != 3 {
+		t.Fatalf("expected 3, got %d", v)
+	}
+}
+
+func TestMutexValue_Load_WithClone(t *testing.T) {
+	mv := NewMutexValue(map[string]int{"a": 1}, WithClone(DeepCopy[map[string]int]))
+
+	m := mv.Load()
+	m["a"] = 2
+
+	if v := mv.Load()["a"]; v != 1 {
+		t.Fatalf("expected Load to return a copy, got %d", v)
+	}
+}
+
+func TestMutexValue_OnChange(t *testing.T) {
+	mv := NewMutexValue(0)
+
+	type event struct{ old, new int }
+	var got []event
+	unsubscribe := mv.OnChange(func(old, new int) {
+		got = append(got, event{old, new})
+	})
+
+	mv.Store(1)
+	mv.Lock(func(v *int) {}) // 未修改，不触发
+	mv.Update(func(old int) int { return old + 1 })
+	mv.Swap(2) // 值相同，不触发
+	_ = mv.Transact(func(v *int) error {
+		*v = 100
+		return errors.New("rollback")
+	})
+
+	want := []event{{0, 1}, {1, 2}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	unsubscribe()
+	mv.Store(3)
+	if len(got) != len(want) {
+		t.Fatalf("expected no callbacks after unsubscribe, got %v", got)
+	}
+}
+
+func TestMutexValue_OnChange_OutsideLock(t *testing.T) {
+	mv := NewMutexValue(0)
+
+	var got []int
+	mv.OnChange(func(old, new int) {
+		// 回调在锁外执行，可以访问值本身，包括再次修改
+		got = append(got, mv.Load())
+		if new < 3 {
+			mv.Store(new + 1)
+		}
+	})
+
+	mv.Store(1)
+
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Fatalf("unexpected callbacks %v", got)
+	}
+}
+
+func TestMutexValue_OnChange_Order(t *testing.T) {
+	mv := NewMutexValue(0)
+
+	var mu sync.Mutex
+	var got []int
+	mv.OnChange(func(old, new int) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(got) > 0 && got[len(got)-1] != old {
+			t.Errorf("expected old=%d, got %d", got[len(got)-1], old)
+		}
+		got = append(got, new)
+	})
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mv.Update(func(old int) int { return old + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != goroutines*100 {
+		t.Fatalf("expected %d callbacks, got %d", goroutines*100, len(got))
+	}
+	for i, v := range got {
+		if v != i+1 {
+			t.Fatalf("expected callbacks in order, got %d at %d", v, i)
+		}
+	}
+}
+
+func TestMutexValue_OnChange_WithEqual(t *testing.T) {
+	type config struct {
+		Host    string
+		Version int
+	}
+
+	mv := NewMutexValue(config{Host: "a"}, WithEqual(func(a, b config) bool {
+		return a.Host == b.Host
+	}))
+
+	var calls int
+	mv.OnChange(func(old, new config) {
+		calls++
+	})
+
+	mv.Lock(func(v *config) { v.Version++ })
+	mv.Lock(func(v *config) { v.Host = "b" })
+
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestMutexValue_OnChange_InPlaceWithClone(t *testing.T) {
+	mv := NewMutexValue(map[string]int{}, WithClone(DeepCopy[map[string]int]))
+
+	var old, new map[string]int
+	mv.OnChange(func(o, n map[string]int) {
+		old, new = o, n
+	})
+
+	mv.Lock(func(v *map[string]int) {
+		(*v)["a"] = 1
+	})
+
+	if len(old) != 0 || new["a"] != 1 {
+		t.Fatalf("unexpected old %v new %v", old, new)
+	}
+}
+
+func TestMutexValue_WithFIFO(t *testing.T) {
+	mv := NewMutexValue(0, WithFIFO())
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mv.Update(func(old int) int { return old + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := mv.Load(); got != goroutines*100 {
+		t.Fatalf("expected %d, got %d", goroutines*100, got)
+	}
+}
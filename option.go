@@ -0,0 +1,111 @@
+package tsync
+
+import "time"
+
+// Option 配置 MutexValue、RWMutexValue 等带锁的值，对不适用的类型会被忽略。
+type Option = func(*options)
+
+// OptionOf 是绑定值类型的选项，只能用于同一 T 的 NewMutexValue 等构造函数，
+// 类型不匹配会在编译期报错。Option 可直接赋值给任意 OptionOf[T]。
+type OptionOf[T any] func(*options)
+
+type options struct {
+	clone     any
+	equal     any
+	name      string
+	metrics   Metrics
+	warnAfter time.Duration
+	warn      func(HoldInfo)
+	fifo      bool
+	policy    RWPolicy
+}
+
+// WithClone 指定深拷贝函数，用于 Load 返回的值以及 Transact 等需要在副本上操作的场景，
+// 避免调用方与锁内的值共享 slice、map 等内存。
+func WithClone[T any](fn func(v T) T) OptionOf[T] {
+	return func(o *options) {
+		o.clone = fn
+	}
+}
+
+// WithEqual 指定 OnChange 判断值是否被修改时使用的相等函数，默认使用 reflect.DeepEqual。
+func WithEqual[T any](fn func(a, b T) bool) OptionOf[T] {
+	return func(o *options) {
+		o.equal = fn
+	}
+}
+
+// WithFIFO 让 Mutex（包括 MutexValue 使用的锁）严格按到达顺序把锁交给等待者，
+// 有等待者时新到达的 goroutine 不能插队。竞争激烈时每次交接都要唤醒等待者，吞吐量低于默认模式。
+func WithFIFO() Option {
+	return func(o *options) {
+		o.fifo = true
+	}
+}
+
+// WithRWPolicy 指定 RWMutex（包括 RWMutexValue 使用的锁）的读写策略，默认 RWPhaseFair。
+func WithRWPolicy(p RWPolicy) Option {
+	return func(o *options) {
+		o.policy = p
+	}
+}
+
+// WithName 为值的锁命名，用于 Metrics 等诊断输出。
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithMetrics 把锁的等待时间、持有时间、获取次数和等待者数量上报给 m。
+// 未指定时锁不做任何统计，也不产生额外开销。
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithHoldWarning 在某次持有锁超过 threshold 时调用 fn，此时持有者仍在锁内，
+// HoldInfo.Stack 是它当时的调用栈，用于在线上定位在锁内做 I/O 等耗时操作的代码。
+// 每次获取至多告警一次；fn 在独立的 goroutine 中执行。开启后每次获取额外开销约数微秒。
+func WithHoldWarning(threshold time.Duration, fn func(info HoldInfo)) Option {
+	return func(o *options) {
+		o.warnAfter = threshold
+		o.warn = fn
+	}
+}
+
+func newOptions[O ~func(*options)](opts []O) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// cloneOption 返回 WithClone 指定的拷贝函数，未指定时回退到 Cloner[T]。
+func cloneOption[T any](o *options) func(T) T {
+	// OptionOf[T] 保证了 clone 的类型
+	if clone, ok := o.clone.(func(T) T); ok {
+		return clone
+	}
+
+	var zero T
+	if _, ok := any(zero).(Cloner[T]); ok {
+		return func(v T) T {
+			return any(v).(Cloner[T]).Clone()
+		}
+	}
+	if _, ok := any(&zero).(Cloner[T]); ok {
+		return func(v T) T {
+			return any(&v).(Cloner[T]).Clone()
+		}
+	}
+	return nil
+}
+
+func equalOption[T any](o *options) func(a, b T) bool {
+	// OptionOf[T] 保证了 equal 的类型
+	equal, _ := o.equal.(func(a, b T) bool)
+	return equal
+}
@@ -1,25 +1,130 @@
 package tsync
 
-import "sync"
+import (
+	"sync"
+	"sync/atomic"
+)
 
 type Pool[T any] struct {
-	p sync.Pool
+	p       sync.Pool
+	newFn   func() T
+	discard func(T) bool
+	stats   *poolStats
+	track   *poolTracker
 }
 
-func NewPool[T any](newFn func() T) *Pool[T] {
-	return &Pool[T]{
-		p: sync.Pool{
-			New: func() any {
-				return newFn()
-			},
-		},
+type PoolStats struct {
+	Gets    uint64 // Get 调用次数
+	Misses  uint64 // 池为空、回落到 newFn 的次数
+	Puts    uint64 // Put 调用次数
+	Dropped uint64 // 被 discard 拒绝、未放回池中的次数
+}
+
+// PoolOption 是与元素类型无关的选项，可用于任意 Pool[T]。
+type PoolOption = func(*poolOptions)
+
+// PoolOptionOf 是绑定元素类型的选项，类型不匹配会在编译期报错。
+// PoolOption 可直接赋值给任意 PoolOptionOf[T]。
+type PoolOptionOf[T any] func(*poolOptions)
+
+type poolOptions struct {
+	stats   bool
+	discard any
+}
+
+// WithPoolStats 开启 Get/Put 统计，未开启时不产生任何额外开销。
+func WithPoolStats() PoolOption {
+	return func(o *poolOptions) {
+		o.stats = true
+	}
+}
+
+// WithPoolDiscard 设置 Put 时的过滤函数，返回 true 的对象直接丢弃（例如过大的 buffer）。
+func WithPoolDiscard[T any](fn func(v T) bool) PoolOptionOf[T] {
+	return func(o *poolOptions) {
+		o.discard = fn
+	}
+}
+
+type poolStats struct {
+	gets    atomic.Uint64
+	misses  atomic.Uint64
+	puts    atomic.Uint64
+	dropped atomic.Uint64
+}
+
+func NewPool[T any](newFn func() T, opts ...PoolOptionOf[T]) *Pool[T] {
+	var o poolOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := &Pool[T]{newFn: newFn}
+	// PoolOptionOf[T] 保证了 discard 的类型
+	p.discard, _ = o.discard.(func(T) bool)
+	if o.stats {
+		p.stats = &poolStats{}
+	}
+	if debugMode {
+		p.track = newPoolTracker()
 	}
+	return p
 }
 
 func (p *Pool[T]) Get() T {
-	return p.p.Get().(T)
+	if p.stats != nil {
+		p.stats.gets.Add(1)
+	}
+	v, ok := p.p.Get().(T)
+	if !ok {
+		if p.stats != nil {
+			p.stats.misses.Add(1)
+		}
+		v = p.newFn()
+	}
+	if debugMode && p.track != nil {
+		p.track.get(v)
+	}
+	return v
 }
 
 func (p *Pool[T]) Put(v T) {
+	if p.stats != nil {
+		p.stats.puts.Add(1)
+	}
+	if debugMode && p.track != nil {
+		p.track.put(v)
+	}
+	if p.discard != nil && p.discard(v) {
+		if p.stats != nil {
+			p.stats.dropped.Add(1)
+		}
+		if debugMode && p.track != nil {
+			p.track.discard(v)
+		}
+		return
+	}
 	p.p.Put(v)
 }
+
+func (p *Pool[T]) Stats() PoolStats {
+	if p.stats == nil {
+		return PoolStats{}
+	}
+	return PoolStats{
+		Gets:    p.stats.gets.Load(),
+		Misses:  p.stats.misses.Load(),
+		Puts:    p.stats.puts.Load(),
+		Dropped: p.stats.dropped.Load(),
+	}
+}
+
+func (p *Pool[T]) ResetStats() {
+	if p.stats == nil {
+		return
+	}
+	p.stats.gets.Store(0)
+	p.stats.misses.Store(0)
+	p.stats.puts.Store(0)
+	p.stats.dropped.Store(0)
+}
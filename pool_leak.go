@@ -0,0 +1,167 @@
+package tsync
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// PoolLeak 描述一个通过 Get 借出但尚未 Put 回的对象。
+type PoolLeak struct {
+	Object any
+	Stack  string // Get 调用处的栈
+}
+
+// TestingT 是 *testing.T 和 *testing.B 的子集。
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Cleanup(fn func())
+}
+
+// CheckPoolLeaks 在测试结束时检查 p 中是否还有未归还的对象。
+// 仅在 tsync_debug 构建下生效，否则不做任何事。
+func CheckPoolLeaks[T any](t TestingT, p *Pool[T]) {
+	t.Helper()
+	t.Cleanup(func() {
+		t.Helper()
+		for _, leak := range p.Outstanding() {
+			t.Errorf("tsync.Pool: object %v was never put back, borrowed at:\n%s", leak.Object, leak.Stack)
+		}
+	})
+}
+
+// Outstanding 返回当前已借出未归还的对象，仅在 tsync_debug 构建下有效。
+// 只有指针、map 和 chan 这类身份不随使用改变的对象会被跟踪。slice 不被跟踪，
+// 因为 append 扩容后底层数组会改变，无法与借出时的对象对应；
+// 需要检测 []byte 等的泄漏时，请在池中存放 *[]byte 或 *bytes.Buffer。
+func (p *Pool[T]) Outstanding() []PoolLeak {
+	if p.track == nil {
+		return nil
+	}
+	return p.track.outstanding()
+}
+
+// maxPoolReturns 限制用于检测重复 Put 的归还记录数量。记录持有对象的引用以免地址
+// 被复用造成误报，不加限制会让被 sync.Pool 丢弃的对象永远无法回收。
+const maxPoolReturns = 1024
+
+type poolTracker struct {
+	mu       sync.Mutex
+	borrowed map[uintptr]PoolLeak
+	returned map[uintptr]poolReturn
+	order    []poolReturnKey // 按归还顺序排列，用于淘汰最早的记录
+	seq      uint64
+}
+
+type poolReturn struct {
+	object any // 持有引用，避免地址被复用造成误报
+	stack  string
+	seq    uint64
+}
+
+type poolReturnKey struct {
+	key uintptr
+	seq uint64
+}
+
+func newPoolTracker() *poolTracker {
+	return &poolTracker{
+		borrowed: make(map[uintptr]PoolLeak),
+		returned: make(map[uintptr]poolReturn),
+	}
+}
+
+func (t *poolTracker) get(v any) {
+	key, ok := poolObjectKey(v)
+	if !ok {
+		return
+	}
+
+	stack := callerStack()
+	t.mu.Lock()
+	delete(t.returned, key)
+	t.borrowed[key] = PoolLeak{Object: v, Stack: stack}
+	t.mu.Unlock()
+}
+
+func (t *poolTracker) put(v any) {
+	key, ok := poolObjectKey(v)
+	if !ok {
+		return
+	}
+
+	stack := callerStack()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if prev, ok := t.returned[key]; ok {
+		panic(fmt.Sprintf("tsync.Pool: object %v put twice, previously put at:\n%s", v, prev.stack))
+	}
+	delete(t.borrowed, key)
+	t.seq++
+	t.returned[key] = poolReturn{object: v, stack: stack, seq: t.seq}
+	t.order = append(t.order, poolReturnKey{key: key, seq: t.seq})
+
+	for len(t.returned) > maxPoolReturns && len(t.order) > 0 {
+		oldest := t.order[0]
+		t.order[0] = poolReturnKey{}
+		t.order = t.order[1:]
+		if t.current(oldest) {
+			delete(t.returned, oldest.key)
+		}
+	}
+
+	// 被 Get 取走或丢弃的记录只从 returned 中删除，这里顺带清理 order
+	if len(t.order) > 2*maxPoolReturns {
+		order := make([]poolReturnKey, 0, len(t.returned))
+		for _, k := range t.order {
+			if t.current(k) {
+				order = append(order, k)
+			}
+		}
+		t.order = order
+	}
+}
+
+func (t *poolTracker) current(k poolReturnKey) bool {
+	r, ok := t.returned[k.key]
+	return ok && r.seq == k.seq
+}
+
+// discard 在归还的对象被 WithPoolDiscard 拒绝时调用：对象不再属于池，
+// 不保留归还记录，以便它被回收。
+func (t *poolTracker) discard(v any) {
+	key, ok := poolObjectKey(v)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	delete(t.returned, key)
+	t.mu.Unlock()
+}
+
+func (t *poolTracker) outstanding() []PoolLeak {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	leaks := make([]PoolLeak, 0, len(t.borrowed))
+	for _, leak := range t.borrowed {
+		leaks = append(leaks, leak)
+	}
+	return leaks
+}
+
+func poolObjectKey(v any) (uintptr, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		if rv.IsNil() {
+			return 0, false
+		}
+		return rv.Pointer(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,178 @@
+//go:build tsync_debug
+
+package tsync
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestPool_Outstanding(t *testing.T) {
+	p := NewPool(func() *int {
+		return new(int)
+	})
+
+	v1 := p.Get()
+	v2 := p.Get()
+
+	if n := len(p.Outstanding()); n != 2 {
+		t.Fatalf("expected 2 outstanding, got %d", n)
+	}
+
+	p.Put(v1)
+	leaks := p.Outstanding()
+	if len(leaks) != 1 || leaks[0].Object != v2 {
+		t.Fatalf("unexpected outstanding %+v", leaks)
+	}
+	if !strings.Contains(leaks[0].Stack, "TestPool_Outstanding") {
+		t.Fatalf("expected Get stack, got:\n%s", leaks[0].Stack)
+	}
+
+	p.Put(v2)
+	if n := len(p.Outstanding()); n != 0 {
+		t.Fatalf("expected 0 outstanding, got %d", n)
+	}
+}
+
+func TestPool_DoublePut(t *testing.T) {
+	p := NewPool(func() *int {
+		return new(int)
+	})
+
+	v := p.Get()
+	p.Put(v)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatalf("expected panic")
+		}
+		if !strings.Contains(fmt.Sprint(r), "put twice") {
+			t.Fatalf("unexpected panic %v", r)
+		}
+	}()
+
+	p.Put(v)
+}
+
+func TestPool_UntrackedValues(t *testing.T) {
+	p := NewPool(func() int {
+		return 0
+	})
+
+	_ = p.Get()
+	p.Put(1)
+	p.Put(1)
+
+	if n := len(p.Outstanding()); n != 0 {
+		t.Fatalf("expected value types to be untracked, got %d", n)
+	}
+}
+
+func TestCheckPoolLeaks(t *testing.T) {
+	p := NewPool(func() *[]byte {
+		b := make([]byte, 0, 16)
+		return &b
+	})
+
+	ft := &fakeT{}
+	CheckPoolLeaks(ft, p)
+
+	b := p.Get()
+	ft.finish()
+	if len(ft.errors) != 1 {
+		t.Fatalf("expected 1 leak reported, got %d", len(ft.errors))
+	}
+
+	p.Put(b)
+
+	ft = &fakeT{}
+	CheckPoolLeaks(ft, p)
+	p.Put(p.Get())
+	ft.finish()
+	if len(ft.errors) != 0 {
+		t.Fatalf("unexpected leaks reported: %v", ft.errors)
+	}
+}
+
+func TestPool_SlicesUntracked(t *testing.T) {
+	p := NewPool(func() []byte {
+		return make([]byte, 0, 4)
+	})
+
+	ft := &fakeT{}
+	CheckPoolLeaks(ft, p)
+
+	// 扩容后底层数组改变，slice 无法与借出时对应，因此不被跟踪
+	b := p.Get()
+	b = append(b, "hello world"...)
+	p.Put(b[:0])
+
+	ft.finish()
+	if len(ft.errors) != 0 {
+		t.Fatalf("unexpected leaks reported: %v", ft.errors)
+	}
+}
+
+func TestPool_DiscardNotRetained(t *testing.T) {
+	p := NewPool(func() *[]byte {
+		b := make([]byte, 0, 16)
+		return &b
+	}, WithPoolDiscard(func(b *[]byte) bool {
+		return cap(*b) > 16
+	}))
+
+	b := p.Get()
+	*b = make([]byte, 0, 1024)
+	p.Put(b)
+
+	if _, ok := p.track.returned[reflect.ValueOf(b).Pointer()]; ok {
+		t.Fatalf("expected discarded object not to be retained")
+	}
+	if n := len(p.Outstanding()); n != 0 {
+		t.Fatalf("expected 0 outstanding, got %d", n)
+	}
+}
+
+func TestPool_ReturnsBounded(t *testing.T) {
+	p := NewPool(func() *int {
+		return new(int)
+	})
+
+	for i := 0; i < 3*maxPoolReturns; i++ {
+		p.Put(new(int))
+	}
+	if n := len(p.track.returned); n > maxPoolReturns {
+		t.Fatalf("expected at most %d return records, got %d", maxPoolReturns, n)
+	}
+
+	for i := 0; i < 3*maxPoolReturns; i++ {
+		p.Put(p.Get())
+	}
+	if n := len(p.track.order); n > 2*maxPoolReturns {
+		t.Fatalf("expected return order to be compacted, got %d", n)
+	}
+}
@@ -84,3 +84,58 @@ func TestPool_GenericType(t *testing.T) {
 		t.Fatalf("unexpected value %+v", v2)
 	}
 }
+
+func TestPool_Stats(t *testing.T) {
+	p := NewPool(func() *int {
+		return new(int)
+	}, WithPoolStats())
+
+	v := p.Get()
+	p.Put(v)
+	_ = p.Get()
+
+	s := p.Stats()
+	if s.Gets != 2 {
+		t.Fatalf("expected 2 gets, got %d", s.Gets)
+	}
+	if s.Misses < 1 || s.Misses > 2 {
+		t.Fatalf("unexpected misses %d", s.Misses)
+	}
+	if s.Puts != 1 {
+		t.Fatalf("expected 1 put, got %d", s.Puts)
+	}
+
+	p.ResetStats()
+	if s := p.Stats(); s != (PoolStats{}) {
+		t.Fatalf("expected zero stats after reset, got %+v", s)
+	}
+}
+
+func TestPool_StatsDisabled(t *testing.T) {
+	p := NewPool(func() int {
+		return 0
+	})
+
+	p.Put(p.Get())
+	p.ResetStats()
+
+	if s := p.Stats(); s != (PoolStats{}) {
+		t.Fatalf("expected zero stats, got %+v", s)
+	}
+}
+
+func TestPool_Discard(t *testing.T) {
+	p := NewPool(func() []byte {
+		return make([]byte, 0, 16)
+	}, WithPoolStats(), WithPoolDiscard(func(b []byte) bool {
+		return cap(b) > 64
+	}))
+
+	p.Put(make([]byte, 0, 128))
+	p.Put(make([]byte, 0, 16))
+
+	s := p.Stats()
+	if s.Puts != 2 || s.Dropped != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+}
@@ -0,0 +1,68 @@
+package tsync
+
+import "sync/atomic"
+
+// ReentrantMutexValue 与 MutexValue 类似，但允许同一 goroutine 在持有锁时再次获取，
+// 适用于有意的递归调用。每次获取都需要解析 goroutine id，开销高于 MutexValue，
+// 仅在确实需要重入时使用。注意在 fn 内启动的 goroutine 不被视为持有者。
+type ReentrantMutexValue[T any] struct {
+	mu    Mutex
+	owner atomic.Int64
+	v     T
+	clone func(T) T
+}
+
+func NewReentrantMutexValue[T any](v T, opts ...OptionOf[T]) *ReentrantMutexValue[T] {
+	o := newOptions(opts)
+	return &ReentrantMutexValue[T]{mu: newMutex(o), v: v, clone: cloneOption[T](o)}
+}
+
+// Lock 在锁内执行 fn；当前 goroutine 已持有锁时直接执行。
+func (m *ReentrantMutexValue[T]) Lock(fn func(v *T)) {
+	id := goid()
+	if m.owner.Load() == id {
+		fn(&m.v)
+		return
+	}
+
+	m.mu.Lock()
+	m.owner.Store(id)
+	defer func() {
+		m.owner.Store(0)
+		m.mu.Unlock()
+	}()
+	fn(&m.v)
+}
+
+// Load 返回当前值的副本；配置了拷贝函数时返回深拷贝。
+func (m *ReentrantMutexValue[T]) Load() (v T) {
+	m.Lock(func(p *T) {
+		v = *p
+		if m.clone != nil {
+			v = m.clone(v)
+		}
+	})
+	return v
+}
+
+func (m *ReentrantMutexValue[T]) Store(v T) {
+	m.Lock(func(p *T) {
+		*p = v
+	})
+}
+
+func (m *ReentrantMutexValue[T]) Swap(v T) (old T) {
+	m.Lock(func(p *T) {
+		old, *p = *p, v
+	})
+	return old
+}
+
+// Update 以 fn 的返回值替换当前值并返回新值。
+func (m *ReentrantMutexValue[T]) Update(fn func(old T) T) (v T) {
+	m.Lock(func(p *T) {
+		*p = fn(*p)
+		v = *p
+	})
+	return v
+}
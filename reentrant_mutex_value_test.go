@@ -0,0 +1,58 @@
+package tsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestReentrantMutexValue_Reentry(t *testing.T) {
+	mv := NewReentrantMutexValue(0)
+
+	var add func(n int)
+	add = func(n int) {
+		if n == 0 {
+			return
+		}
+		mv.Lock(func(v *int) {
+			*v++
+			add(n - 1)
+		})
+	}
+	add(3)
+
+	mv.Lock(func(v *int) {
+		if got := mv.Load(); got != 3 {
+			t.Fatalf("expected 3, got %d", got)
+		}
+		mv.Store(4)
+	})
+
+	if got := mv.Load(); got != 4 {
+		t.Fatalf("expected 4, got %d", got)
+	}
+}
+
+func TestReentrantMutexValue_Exclusive(t *testing.T) {
+	mv := NewReentrantMutexValue(0)
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				mv.Lock(func(v *int) {
+					mv.Update(func(old int) int { return old + 1 })
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if got := mv.Load(); got != goroutines*100 {
+		t.Fatalf("expected %d, got %d", goroutines*100, got)
+	}
+}
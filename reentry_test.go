@@ -0,0 +1,84 @@
+//go:build tsync_debug
+
+package tsync
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func expectReentryPanic(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		msg, _ := r.(string)
+		if !strings.Contains(msg, "reentrant lock") || !strings.Contains(msg, "lock held since") {
+			t.Fatalf("expected reentrant lock panic, got %v", r)
+		}
+	}()
+	fn()
+}
+
+func TestMutexValue_Reentry_Panics(t *testing.T) {
+	mv := NewMutexValue(0)
+
+	expectReentryPanic(t, func() {
+		mv.Lock(func(v *int) {
+			mv.Load()
+		})
+	})
+
+	// panic 后锁已释放
+	mv.Store(1)
+}
+
+func TestRWMutexValue_Reentry_Panics(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	expectReentryPanic(t, func() {
+		mv.RLock(func(v int) {
+			mv.Store(1)
+		})
+	})
+	expectReentryPanic(t, func() {
+		mv.RLock(func(v int) {
+			mv.RLock(func(v int) {})
+		})
+	})
+
+	mv.Store(1)
+}
+
+func TestMutexValue_Reentry_CancellableCtx_Panics(t *testing.T) {
+	mv := NewMutexValue(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	expectReentryPanic(t, func() {
+		mv.Lock(func(v *int) {
+			_ = mv.LockCtx(ctx, func(v *int) {})
+		})
+	})
+
+	// 带截止时间的获取只会超时失败，不视为自死锁
+	mv.Lock(func(v *int) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := mv.LockCtx(ctx, func(v *int) {}); err == nil {
+			t.Fatalf("expected timeout")
+		}
+	})
+}
+
+func TestReentrantMutexValue_NoPanic(t *testing.T) {
+	mv := NewReentrantMutexValue(0)
+
+	mv.Lock(func(v *int) {
+		mv.Store(1)
+	})
+	if got := mv.Load(); got != 1 {
+		t.Fatalf("expected 1, got %d", got)
+	}
+}
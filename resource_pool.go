@@ -0,0 +1,562 @@
+package tsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrPoolClosed = errors.New("tsync.ResourcePool: pool closed")
+
+// ErrDuplicateResource 表示 factory 返回的资源与池中某个现存资源相等。
+// 资源池以资源值本身区分 Release 和 Discard 的对象，因此要求 factory 每次返回互不相等的值，
+// 例如指针或带唯一编号的句柄。重复的资源不会交给 WithCloseFunc，以免误关闭与之相等的现存资源。
+var ErrDuplicateResource = errors.New("tsync.ResourcePool: factory returned a resource equal to a live one")
+
+type ResourcePool[T comparable] struct {
+	factory     func(ctx context.Context) (T, error)
+	check       func(v T) error
+	closeFn     func(v T)
+	minSize     int
+	maxSize     int
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+
+	mu      sync.Mutex
+	idle    []*resource[T]
+	inUse   map[T]*resource[T]
+	live    map[T]struct{} // 所有现存资源，包括空闲和正在交接的
+	waiters []chan *resource[T]
+	total   int // 已创建或正在创建的资源数
+	active  int // 已借出或正在获取中的数量
+	closed  bool
+	drained chan struct{}
+	ctx     context.Context // 后台补足资源时使用，Close 时取消
+	cancel  context.CancelFunc
+	reaping sync.WaitGroup
+	refill  chan struct{} // 资源数低于 minSize 时通知后台补足，未设置 minSize 时为 nil
+}
+
+type resource[T any] struct {
+	v         T
+	createdAt time.Time
+	idleAt    time.Time // 最近一次放回空闲队列的时间
+}
+
+// ResourcePoolOption 是与资源类型无关的选项，可用于任意 ResourcePool[T]。
+type ResourcePoolOption = func(*resourcePoolOptions)
+
+// ResourcePoolOptionOf 是绑定资源类型的选项，类型不匹配会在编译期报错。
+// ResourcePoolOption 可直接赋值给任意 ResourcePoolOptionOf[T]。
+type ResourcePoolOptionOf[T any] func(*resourcePoolOptions)
+
+type resourcePoolOptions struct {
+	minSize     int
+	maxSize     int
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+	check       any
+	closeFn     any
+}
+
+// WithMinSize 设置最少保留的资源数。创建资源池时预先创建，之后资源因过期、
+// 健康检查失败或 Discard 被销毁而低于该数量时，由后台 goroutine 补足；
+// 补足时 factory 出错则等到下一次销毁或过期检查时再重试。
+func WithMinSize(n int) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.minSize = n
+	}
+}
+
+func WithMaxSize(n int) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.maxSize = n
+	}
+}
+
+// WithIdleTimeout 设置资源在空闲队列中的最长停留时间，借出期间不计入。
+func WithIdleTimeout(d time.Duration) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.idleTimeout = d
+	}
+}
+
+func WithMaxLifetime(d time.Duration) ResourcePoolOption {
+	return func(o *resourcePoolOptions) {
+		o.maxLifetime = d
+	}
+}
+
+// WithHealthCheck 在从空闲队列取出资源时调用，返回错误的资源会被销毁。
+func WithHealthCheck[T any](fn func(v T) error) ResourcePoolOptionOf[T] {
+	return func(o *resourcePoolOptions) {
+		o.check = fn
+	}
+}
+
+// WithCloseFunc 在资源被销毁时调用。
+func WithCloseFunc[T any](fn func(v T)) ResourcePoolOptionOf[T] {
+	return func(o *resourcePoolOptions) {
+		o.closeFn = fn
+	}
+}
+
+func NewResourcePool[T comparable](
+	ctx context.Context,
+	factory func(ctx context.Context) (T, error),
+	opts ...ResourcePoolOptionOf[T],
+) (*ResourcePool[T], error) {
+	if factory == nil {
+		panic("tsync.ResourcePool: nil factory")
+	}
+
+	o := resourcePoolOptions{maxSize: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxSize < 1 || o.minSize < 0 || o.minSize > o.maxSize {
+		panic("tsync.ResourcePool: invalid size limits")
+	}
+
+	p := &ResourcePool[T]{
+		factory:     factory,
+		minSize:     o.minSize,
+		maxSize:     o.maxSize,
+		idleTimeout: o.idleTimeout,
+		maxLifetime: o.maxLifetime,
+		inUse:       make(map[T]*resource[T]),
+		live:        make(map[T]struct{}),
+		drained:     make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	// ResourcePoolOptionOf[T] 保证了 check 与 closeFn 的类型
+	p.check, _ = o.check.(func(T) error)
+	p.closeFn, _ = o.closeFn.(func(T))
+
+	if p.minSize > 0 {
+		p.refill = make(chan struct{}, 1)
+	}
+
+	for i := 0; i < p.minSize; i++ {
+		r, err := p.create(ctx)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.mu.Lock()
+		if !p.add(r) {
+			p.mu.Unlock()
+			p.Close()
+			return nil, ErrDuplicateResource
+		}
+		p.total++
+		p.putIdle(r)
+		p.mu.Unlock()
+	}
+
+	if interval := p.reapInterval(); interval > 0 || p.refill != nil {
+		p.reaping.Add(1)
+		go p.reaper(interval)
+	}
+
+	return p, nil
+}
+
+func (p *ResourcePool[T]) Acquire(ctx context.Context) (T, error) {
+	var zero T
+
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return zero, ErrPoolClosed
+		}
+
+		r, expired := p.popIdle()
+		switch {
+		case r != nil:
+			p.active++
+			p.mu.Unlock()
+			p.closeResources(expired)
+		case p.total < p.maxSize:
+			p.total++
+			p.active++
+			p.mu.Unlock()
+			p.closeResources(expired)
+			return p.createInUse(ctx)
+		default:
+			ch := make(chan *resource[T], 1)
+			p.waiters = append(p.waiters, ch)
+			p.mu.Unlock()
+			p.closeResources(expired)
+
+			var ok bool
+			select {
+			case r, ok = <-ch:
+			case <-ctx.Done():
+				p.cancelWait(ch)
+				return zero, ctx.Err()
+			}
+			if !ok {
+				return zero, ErrPoolClosed
+			}
+			// nil 表示获得了创建新资源的名额
+			if r == nil {
+				return p.createInUse(ctx)
+			}
+		}
+
+		if p.check != nil {
+			if err := p.check(r.v); err != nil {
+				p.closeResource(r)
+				p.mu.Lock()
+				p.drop(r)
+				p.done()
+				p.signal()
+				p.mu.Unlock()
+				continue
+			}
+		}
+
+		p.mu.Lock()
+		p.inUse[r.v] = r
+		p.mu.Unlock()
+		return r.v, nil
+	}
+}
+
+func (p *ResourcePool[T]) Release(v T) {
+	p.mu.Lock()
+	r, ok := p.inUse[v]
+	if !ok {
+		p.mu.Unlock()
+		panic("tsync.ResourcePool: release of unknown resource")
+	}
+	delete(p.inUse, v)
+	destroy := p.release(r)
+	p.mu.Unlock()
+
+	if destroy {
+		p.closeResource(r)
+	}
+}
+
+// Discard 销毁一个已借出但不可再用的资源，例如连接已断开。
+func (p *ResourcePool[T]) Discard(v T) {
+	p.mu.Lock()
+	r, ok := p.inUse[v]
+	if !ok {
+		p.mu.Unlock()
+		panic("tsync.ResourcePool: discard of unknown resource")
+	}
+	delete(p.inUse, v)
+	p.drop(r)
+	p.done()
+	p.signal()
+	p.mu.Unlock()
+
+	p.closeResource(r)
+}
+
+// Close 关闭资源池，销毁空闲资源，取消正在进行的补足，
+// 并等待后台 goroutine 退出以及所有已借出的资源归还。
+func (p *ResourcePool[T]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		p.reaping.Wait()
+		<-p.drained
+		return
+	}
+	p.closed = true
+	p.cancel()
+
+	idle := p.idle
+	p.idle = nil
+	for _, r := range idle {
+		p.drop(r)
+	}
+
+	for _, ch := range p.waiters {
+		close(ch)
+	}
+	p.waiters = nil
+
+	if p.active == 0 {
+		close(p.drained)
+	}
+	p.mu.Unlock()
+
+	p.closeResources(idle)
+	p.reaping.Wait()
+	<-p.drained
+}
+
+func (p *ResourcePool[T]) createInUse(ctx context.Context) (T, error) {
+	r, err := p.create(ctx)
+	if err != nil {
+		p.mu.Lock()
+		p.total--
+		p.done()
+		p.signal()
+		p.mu.Unlock()
+
+		var zero T
+		return zero, err
+	}
+
+	p.mu.Lock()
+	if !p.add(r) {
+		p.total--
+		p.done()
+		p.signal()
+		p.mu.Unlock()
+
+		var zero T
+		return zero, ErrDuplicateResource
+	}
+	p.inUse[r.v] = r
+	p.mu.Unlock()
+	return r.v, nil
+}
+
+func (p *ResourcePool[T]) create(ctx context.Context) (*resource[T], error) {
+	v, err := p.factory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &resource[T]{v: v, createdAt: time.Now()}, nil
+}
+
+// cancelWait 处理等待期间 ctx 取消：若已被分配了资源或创建名额则归还。
+func (p *ResourcePool[T]) cancelWait(ch chan *resource[T]) {
+	p.mu.Lock()
+	for i, w := range p.waiters {
+		if w == ch {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			p.mu.Unlock()
+			return
+		}
+	}
+	p.mu.Unlock()
+
+	r, ok := <-ch
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	destroy := false
+	if r == nil {
+		p.total--
+		p.done()
+		p.signal()
+	} else {
+		destroy = p.release(r)
+	}
+	p.mu.Unlock()
+
+	if destroy {
+		p.closeResource(r)
+	}
+}
+
+// 以下方法均需在持有 mu 时调用。
+
+// add 登记一个新创建的资源，与现存资源相等时返回 false。
+func (p *ResourcePool[T]) add(r *resource[T]) bool {
+	if _, ok := p.live[r.v]; ok {
+		return false
+	}
+	p.live[r.v] = struct{}{}
+	return true
+}
+
+// drop 注销一个即将销毁的资源，资源数低于 minSize 时通知后台补足。
+func (p *ResourcePool[T]) drop(r *resource[T]) {
+	delete(p.live, r.v)
+	p.total--
+	if !p.closed && p.total < p.minSize {
+		select {
+		case p.refill <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// release 归还一个借出的资源，返回 true 表示调用方需要销毁它。
+func (p *ResourcePool[T]) release(r *resource[T]) bool {
+	p.done()
+	if p.closed || p.tooOld(r, time.Now()) {
+		p.drop(r)
+		p.signal()
+		return true
+	}
+	p.putIdle(r)
+	return false
+}
+
+// done 减少借出计数，池关闭且全部归还后唤醒 Close。
+func (p *ResourcePool[T]) done() {
+	p.active--
+	if p.closed && p.active == 0 {
+		close(p.drained)
+	}
+}
+
+// signal 在有空余容量时把一个创建名额转交给等待者。
+func (p *ResourcePool[T]) signal() {
+	if p.closed || len(p.waiters) == 0 || p.total >= p.maxSize {
+		return
+	}
+	ch := p.waiters[0]
+	p.waiters = p.waiters[1:]
+	p.total++
+	p.active++
+	ch <- nil
+}
+
+// putIdle 优先把资源直接交给等待者，否则放回空闲队列。
+func (p *ResourcePool[T]) putIdle(r *resource[T]) {
+	if len(p.waiters) > 0 {
+		ch := p.waiters[0]
+		p.waiters = p.waiters[1:]
+		p.active++
+		ch <- r
+		return
+	}
+	r.idleAt = time.Now()
+	p.idle = append(p.idle, r)
+}
+
+// popIdle 取出最近归还的未过期资源，并返回途中淘汰的过期资源。
+func (p *ResourcePool[T]) popIdle() (*resource[T], []*resource[T]) {
+	now := time.Now()
+	var expired []*resource[T]
+	for len(p.idle) > 0 {
+		r := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !p.expired(r, now) {
+			return r, expired
+		}
+		p.drop(r)
+		expired = append(expired, r)
+	}
+	return nil, expired
+}
+
+// expired 判断空闲队列中的资源是否应被淘汰。
+func (p *ResourcePool[T]) expired(r *resource[T], now time.Time) bool {
+	if p.tooOld(r, now) {
+		return true
+	}
+	return p.idleTimeout > 0 && now.Sub(r.idleAt) >= p.idleTimeout
+}
+
+// tooOld 判断资源是否超过最大存活时间。借出的时间不计入空闲超时，
+// 因此归还时只检查这一项。
+func (p *ResourcePool[T]) tooOld(r *resource[T], now time.Time) bool {
+	return p.maxLifetime > 0 && now.Sub(r.createdAt) >= p.maxLifetime
+}
+
+func (p *ResourcePool[T]) closeResource(r *resource[T]) {
+	if p.closeFn != nil {
+		p.closeFn(r.v)
+	}
+}
+
+func (p *ResourcePool[T]) closeResources(rs []*resource[T]) {
+	for _, r := range rs {
+		p.closeResource(r)
+	}
+}
+
+func (p *ResourcePool[T]) reapInterval() time.Duration {
+	var d time.Duration
+	for _, v := range []time.Duration{p.idleTimeout, p.maxLifetime} {
+		if v > 0 && (d == 0 || v < d) {
+			d = v
+		}
+	}
+	if d == 0 {
+		return 0
+	}
+	if d /= 2; d < time.Millisecond {
+		d = time.Millisecond
+	}
+	return d
+}
+
+// reaper 定期淘汰过期的空闲资源，并在收到通知时补足最小资源数。
+// interval 为 0 表示没有设置超时，只响应补足通知。
+func (p *ResourcePool[T]) reaper(interval time.Duration) {
+	defer p.reaping.Done()
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-tick:
+			p.reap()
+		case <-p.refill:
+			p.reap()
+		}
+	}
+}
+
+func (p *ResourcePool[T]) reap() {
+	now := time.Now()
+
+	p.mu.Lock()
+	var expired []*resource[T]
+	kept := p.idle[:0]
+	for _, r := range p.idle {
+		if p.expired(r, now) {
+			p.drop(r)
+			expired = append(expired, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	p.idle = kept
+
+	// 补足最小资源数
+	refill := 0
+	if !p.closed && p.total < p.minSize {
+		refill = p.minSize - p.total
+		p.total += refill
+	}
+	p.mu.Unlock()
+
+	p.closeResources(expired)
+
+	for i := 0; i < refill; i++ {
+		r, err := p.create(p.ctx)
+
+		p.mu.Lock()
+		switch {
+		case err != nil:
+			p.total--
+			p.signal()
+		case !p.closed && !p.add(r):
+			p.total--
+			p.signal()
+		case p.closed:
+			p.total--
+			p.mu.Unlock()
+			p.closeResource(r)
+			continue
+		default:
+			p.putIdle(r)
+		}
+		p.mu.Unlock()
+	}
+}
@@ -0,0 +1,524 @@
+package tsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	id     int
+	broken atomic.Bool
+	closed atomic.Bool
+}
+
+func newTestConnFactory(created *atomic.Int32) func(ctx context.Context) (*testConn, error) {
+	return func(ctx context.Context) (*testConn, error) {
+		return &testConn{id: int(created.Add(1))}, nil
+	}
+}
+
+func TestResourcePool_AcquireRelease(t *testing.T) {
+	var created atomic.Int32
+	p, err := NewResourcePool(context.Background(), newTestConnFactory(&created), WithMaxSize(2))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer p.Close()
+
+	c1, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	p.Release(c1)
+
+	c2, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if c2 != c1 {
+		t.Fatalf("expected idle resource to be reused")
+	}
+	p.Release(c2)
+
+	if created.Load() != 1 {
+		t.Fatalf("expected 1 resource created, got %d", created.Load())
+	}
+}
+
+func TestResourcePool_MinSize(t *testing.T) {
+	var created atomic.Int32
+	p, err := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMinSize(3), WithMaxSize(5))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer p.Close()
+
+	if created.Load() != 3 {
+		t.Fatalf("expected 3 resources created, got %d", created.Load())
+	}
+}
+
+func TestResourcePool_MinSize_RefillAfterDiscard(t *testing.T) {
+	var created atomic.Int32
+	p, err := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMinSize(2), WithMaxSize(3))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer p.Close()
+
+	c, _ := p.Acquire(context.Background())
+	p.Discard(c)
+
+	// 没有设置超时也会补足最小资源数
+	waitFor(t, func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return len(p.idle) == 2
+	})
+	if created.Load() != 3 {
+		t.Fatalf("expected 3 resources created, got %d", created.Load())
+	}
+}
+
+func TestResourcePool_Close_CancelsRefill(t *testing.T) {
+	var calls, closed atomic.Int32
+	p, err := NewResourcePool(context.Background(), func(ctx context.Context) (*testConn, error) {
+		n := calls.Add(1)
+		if n > 1 {
+			// 后台补足：一直阻塞到 Close 取消 ctx
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+		}
+		return &testConn{id: int(n)}, nil
+	}, WithMinSize(1), WithCloseFunc(func(c *testConn) {
+		closed.Add(1)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	c, _ := p.Acquire(context.Background())
+	p.Discard(c)
+	waitFor(t, func() bool { return calls.Load() == 2 })
+
+	done := make(chan struct{})
+	go func() {
+		p.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not cancel the refill")
+	}
+
+	// 补足得到的资源在 Close 返回前就已销毁
+	if got := closed.Load(); got != 2 {
+		t.Fatalf("expected 2 resources closed before Close returned, got %d", got)
+	}
+}
+
+func TestResourcePool_FactoryError(t *testing.T) {
+	errDial := errors.New("dial failed")
+	_, err := NewResourcePool(context.Background(), func(ctx context.Context) (*testConn, error) {
+		return nil, errDial
+	}, WithMinSize(1))
+	if !errors.Is(err, errDial) {
+		t.Fatalf("expected %v, got %v", errDial, err)
+	}
+}
+
+func TestResourcePool_DuplicateResource(t *testing.T) {
+	var closed atomic.Int32
+	p, err := NewResourcePool(context.Background(), func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, WithMaxSize(2), WithCloseFunc(func(v int) {
+		closed.Add(1)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	v1, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := p.Acquire(context.Background()); !errors.Is(err, ErrDuplicateResource) {
+		t.Fatalf("expected %v, got %v", ErrDuplicateResource, err)
+	}
+	if closed.Load() != 0 {
+		t.Fatalf("expected duplicate resource not to be closed")
+	}
+
+	// 重复的资源不占用名额，原资源仍可正常归还和复用
+	p.Release(v1)
+	v2, err := p.Acquire(context.Background())
+	if err != nil || v2 != v1 {
+		t.Fatalf("expected idle resource to be reused, got %v %v", v2, err)
+	}
+	p.Release(v2)
+	p.Close()
+
+	if closed.Load() != 1 {
+		t.Fatalf("expected 1 resource closed, got %d", closed.Load())
+	}
+}
+
+func TestResourcePool_DuplicateResource_MinSize(t *testing.T) {
+	_, err := NewResourcePool(context.Background(), func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, WithMinSize(2), WithMaxSize(2))
+	if !errors.Is(err, ErrDuplicateResource) {
+		t.Fatalf("expected %v, got %v", ErrDuplicateResource, err)
+	}
+}
+
+func TestResourcePool_AcquireBlocksUntilRelease(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created), WithMaxSize(1))
+	defer p.Close()
+
+	c, _ := p.Acquire(context.Background())
+
+	got := make(chan *testConn)
+	go func() {
+		v, err := p.Acquire(context.Background())
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+		got <- v
+	}()
+
+	select {
+	case <-got:
+		t.Fatalf("expected Acquire to block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.Release(c)
+
+	select {
+	case v := <-got:
+		if v != c {
+			t.Fatalf("expected released resource to be handed over")
+		}
+		p.Release(v)
+	case <-time.After(time.Second):
+		t.Fatalf("expected Acquire to proceed after Release")
+	}
+}
+
+func TestResourcePool_AcquireTimeout(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created), WithMaxSize(1))
+	defer p.Close()
+
+	c, _ := p.Acquire(context.Background())
+	defer p.Release(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestResourcePool_HealthCheck(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(1),
+		WithHealthCheck(func(c *testConn) error {
+			if c.broken.Load() {
+				return errors.New("broken")
+			}
+			return nil
+		}),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	c1.broken.Store(true)
+	p.Release(c1)
+
+	c2, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer p.Release(c2)
+
+	if c2 == c1 {
+		t.Fatalf("expected broken resource to be replaced")
+	}
+	if !c1.closed.Load() {
+		t.Fatalf("expected broken resource to be closed")
+	}
+}
+
+func TestResourcePool_Discard(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(1),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	p.Discard(c1)
+
+	if !c1.closed.Load() {
+		t.Fatalf("expected discarded resource to be closed")
+	}
+
+	c2, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	p.Release(c2)
+}
+
+func TestResourcePool_MaxLifetime(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(1),
+		WithMaxLifetime(20*time.Millisecond),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	p.Release(c1)
+
+	if !c1.closed.Load() {
+		t.Fatalf("expected expired resource to be closed on release")
+	}
+}
+
+func TestResourcePool_Acquire_ClosesExpiredIdle(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(2),
+		WithMaxLifetime(time.Hour),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	c2, _ := p.Acquire(context.Background())
+	p.Release(c2)
+	p.Release(c1)
+
+	// c1 在空闲队列顶部且已过期，c2 在它后面且仍然可用
+	p.mu.Lock()
+	p.idle[1].createdAt = time.Now().Add(-2 * time.Hour)
+	p.mu.Unlock()
+
+	c, err := p.Acquire(context.Background())
+	if err != nil || c != c2 {
+		t.Fatalf("expected the fresh resource, got %v %v", c, err)
+	}
+	if !c1.closed.Load() {
+		t.Fatalf("expected expired resource to be closed")
+	}
+	p.mu.Lock()
+	total := p.total
+	p.mu.Unlock()
+	if total != 1 {
+		t.Fatalf("expected total 1, got %d", total)
+	}
+	p.Release(c)
+}
+
+func TestResourcePool_IdleTimeout(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(1),
+		WithIdleTimeout(20*time.Millisecond),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+	defer p.Close()
+
+	c1, _ := p.Acquire(context.Background())
+	p.Release(c1)
+
+	deadline := time.Now().Add(time.Second)
+	for !c1.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected idle resource to be evicted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestResourcePool_IdleTimeout_NotAppliedWhileInUse(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(1),
+		WithIdleTimeout(time.Hour),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+	defer p.Close()
+
+	// 归还后放回空闲队列，再借出并持有超过空闲超时
+	c1, _ := p.Acquire(context.Background())
+	p.Release(c1)
+	c1, _ = p.Acquire(context.Background())
+	p.mu.Lock()
+	p.inUse[c1].idleAt = time.Now().Add(-2 * time.Hour)
+	p.mu.Unlock()
+	p.Release(c1)
+
+	if c1.closed.Load() {
+		t.Fatalf("expected idle timeout not to count time in use")
+	}
+	c2, _ := p.Acquire(context.Background())
+	if c2 != c1 {
+		t.Fatalf("expected resource to be reused")
+	}
+	p.Release(c2)
+}
+
+func TestResourcePool_CloseWaitsForOutstanding(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created),
+		WithMaxSize(1),
+		WithCloseFunc(func(c *testConn) {
+			c.closed.Store(true)
+		}),
+	)
+
+	c, _ := p.Acquire(context.Background())
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatalf("expected Close to wait for outstanding resource")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.Release(c)
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatalf("expected Close to return after Release")
+	}
+
+	if !c.closed.Load() {
+		t.Fatalf("expected resource to be closed")
+	}
+	if _, err := p.Acquire(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("expected ErrPoolClosed, got %v", err)
+	}
+}
+
+func TestResourcePool_Concurrent(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created), WithMaxSize(3))
+	defer p.Close()
+
+	const goroutines = 20
+	const iterations = 50
+
+	var inUse, maxInUse atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				c, err := p.Acquire(context.Background())
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+					return
+				}
+				n := inUse.Add(1)
+				for {
+					m := maxInUse.Load()
+					if n <= m || maxInUse.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				inUse.Add(-1)
+				p.Release(c)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if maxInUse.Load() > 3 {
+		t.Fatalf("expected at most 3 resources in use, got %d", maxInUse.Load())
+	}
+	if created.Load() > 3 {
+		t.Fatalf("expected at most 3 resources created, got %d", created.Load())
+	}
+}
+
+func TestResourcePool_ConcurrentCancel(t *testing.T) {
+	var created atomic.Int32
+	p, _ := NewResourcePool(context.Background(), newTestConnFactory(&created), WithMaxSize(2))
+
+	const goroutines = 20
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%3)*time.Millisecond)
+				c, err := p.Acquire(ctx)
+				cancel()
+				if err == nil {
+					time.Sleep(time.Millisecond)
+					p.Release(c)
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	c1, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c2, err := p.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	p.Release(c1)
+	p.Release(c2)
+	p.Close()
+
+	if created.Load() > 2 {
+		t.Fatalf("expected at most 2 resources created, got %d", created.Load())
+	}
+}
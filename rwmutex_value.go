@@ -1,14 +1,27 @@
 package tsync
 
-import "sync"
+import (
+	"bytes"
+	"context"
+	"unsafe"
+)
 
 type RWMutexValue[T any] struct {
-	mu sync.RWMutex
-	v  T
+	mu      RWMutex
+	v       T
+	clone   func(T) T
+	changes changeHooks[T]
 }
 
-func NewRWMutexValue[T any](v T) *RWMutexValue[T] {
-	return &RWMutexValue[T]{v: v}
+func NewRWMutexValue[T any](v T, opts ...OptionOf[T]) *RWMutexValue[T] {
+	o := newOptions(opts)
+	clone := cloneOption[T](o)
+	return &RWMutexValue[T]{
+		mu:      newRWMutex(o),
+		v:       v,
+		clone:   clone,
+		changes: changeHooks[T]{clone: clone, equal: equalOption[T](o)},
+	}
 }
 
 func (m *RWMutexValue[T]) RLock(fn func(v T)) {
@@ -17,8 +30,218 @@ func (m *RWMutexValue[T]) RLock(fn func(v T)) {
 	fn(m.v)
 }
 
+// RLockPtr 与 RLock 相同，但以指针传入当前值，避免复制较大的结构体。
+// fn 只能通过 v 读取。在 tsync_debug 构建下，fn 返回后会比较 *v 自身的字节，
+// 被修改则 panic；通过 v 中的 slice、map 或指针对其引用内容的写入不会被发现，
+// 且检测发生在 fn 返回之后，期间并发的读者可能已经观察到修改。
+func (m *RWMutexValue[T]) RLockPtr(fn func(v *T)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if debugMode {
+		defer checkReadOnly(&m.v, bytes.Clone(valueBytes(&m.v)))
+	}
+	fn(&m.v)
+}
+
 func (m *RWMutexValue[T]) Lock(fn func(v *T)) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	fn(&m.v)
+}
+
+// Load 返回当前值的副本；配置了拷贝函数时返回深拷贝。
+func (m *RWMutexValue[T]) Load() T {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.clone != nil {
+		return m.clone(m.v)
+	}
+	return m.v
+}
+
+func (m *RWMutexValue[T]) Store(v T) {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	m.v = v
+}
+
+func (m *RWMutexValue[T]) Swap(v T) (old T) {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	old, m.v = m.v, v
+	return old
+}
+
+// Update 以 fn 的返回值替换当前值并返回新值。
+func (m *RWMutexValue[T]) Update(fn func(old T) T) T {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	m.v = fn(m.v)
+	return m.v
+}
+
+// RLockUpgradable 在可升级读锁内执行 fn。可升级读锁同一时刻至多一个持有者，
+// 与普通读者共存但与写者互斥。在 fn 内调用 upgrade 会等待其他读者退出后
+// 原子地升级为写锁执行 write，返回后恢复为可升级读锁，期间不会有其他写者插入。
+// 注意 fn 收到的 v 是进入时的副本，不反映 write 中的修改。
+func (m *RWMutexValue[T]) RLockUpgradable(fn func(v T, upgrade func(write func(v *T)))) {
+	m.mu.upgradableRLock()
+
+	returned, changed := false, false
+	defer func() {
+		returned = true
+		m.mu.upgradableRUnlock()
+		if changed {
+			m.changes.notify()
+		}
+	}()
+
+	fn(m.v, func(write func(v *T)) {
+		if returned {
+			panic("tsync.RWMutexValue: upgrade called after RLockUpgradable returned")
+		}
+		m.mu.upgradeLock()
+		defer m.mu.upgradeUnlock()
+
+		if old := m.changes.snapshot(&m.v); old != nil {
+			defer func() {
+				m.changes.record(*old, m.v)
+				changed = true
+			}()
+		}
+		write(&m.v)
+	})
+}
+
+// LockDowngrade 在写锁内执行 write，然后原子地降级为读锁执行 read，
+// 两者之间不会有其他写者插入，其他读者可以在 read 期间进入。
+func (m *RWMutexValue[T]) LockDowngrade(write func(v *T), read func(v T)) {
+	m.mu.Lock()
+	old := m.changes.snapshot(&m.v)
+
+	downgraded := false
+	defer func() {
+		if downgraded {
+			m.mu.RUnlock()
+			if old != nil {
+				m.changes.notify()
+			}
+		} else {
+			m.unlock(old)
+		}
+	}()
+
+	write(&m.v)
+	if old != nil {
+		m.changes.record(*old, m.v)
+	}
+	m.mu.Downgrade()
+	downgraded = true
+	read(m.v)
+}
+
+// TryRLock 仅在能立即获得读锁时执行 fn，并报告是否执行。
+func (m *RWMutexValue[T]) TryRLock(fn func(v T)) bool {
+	if !m.mu.TryRLock() {
+		return false
+	}
+	defer m.mu.RUnlock()
+	fn(m.v)
+	return true
+}
+
+// TryLock 仅在能立即获得写锁时执行 fn，并报告是否执行。
+func (m *RWMutexValue[T]) TryLock(fn func(v *T)) bool {
+	if !m.mu.TryLock() {
+		return false
+	}
+	defer m.unlock(m.changes.snapshot(&m.v))
 	fn(&m.v)
+	return true
+}
+
+// RLockCtx 与 RLock 相同，但在获得锁之前 ctx 结束时放弃并返回 ctx.Err()。
+func (m *RWMutexValue[T]) RLockCtx(ctx context.Context, fn func(v T)) error {
+	if err := m.mu.RLockCtx(ctx); err != nil {
+		return err
+	}
+	defer m.mu.RUnlock()
+	fn(m.v)
+	return nil
+}
+
+// LockCtx 与 Lock 相同，但在获得锁之前 ctx 结束时放弃并返回 ctx.Err()。
+func (m *RWMutexValue[T]) LockCtx(ctx context.Context, fn func(v *T)) error {
+	if err := m.mu.LockCtx(ctx); err != nil {
+		return err
+	}
+	defer m.unlock(m.changes.snapshot(&m.v))
+	fn(&m.v)
+	return nil
+}
+
+// RLockErr 与 RLock 相同，但把 fn 返回的错误传递给调用方。
+func (m *RWMutexValue[T]) RLockErr(fn func(v T) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fn(m.v)
+}
+
+// LockErr 与 Lock 相同，但把 fn 返回的错误传递给调用方。
+func (m *RWMutexValue[T]) LockErr(fn func(v *T) error) error {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	return fn(&m.v)
+}
+
+// WithRLock 在 m 的读锁内执行 fn 并返回其结果。
+func WithRLock[T, R any](m *RWMutexValue[T], fn func(v T) R) R {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fn(m.v)
+}
+
+// WithWLock 在 m 的写锁内执行 fn 并返回其结果。
+func WithWLock[T, R any](m *RWMutexValue[T], fn func(v *T) R) R {
+	m.mu.Lock()
+	defer m.unlock(m.changes.snapshot(&m.v))
+	return fn(&m.v)
+}
+
+// WithRLockPtr 在 m 的读锁内以指针执行 fn 并返回其结果，约束同 RLockPtr。
+func WithRLockPtr[T, R any](m *RWMutexValue[T], fn func(v *T) R) R {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if debugMode {
+		defer checkReadOnly(&m.v, bytes.Clone(valueBytes(&m.v)))
+	}
+	return fn(&m.v)
+}
+
+// OnChange 注册在值被修改后调用的 fn，约定同 MutexValue.OnChange。
+func (m *RWMutexValue[T]) OnChange(fn func(old, new T)) (unsubscribe func()) {
+	return m.changes.add(fn)
+}
+
+// unlock 释放写锁；old 非 nil 时记录本次修改，并在锁外通知 OnChange 的回调。
+func (m *RWMutexValue[T]) unlock(old *T) {
+	if old == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.changes.record(*old, m.v)
+	m.mu.Unlock()
+	m.changes.notify()
+}
+
+// valueBytes 返回 *p 自身占用的内存，不包含其引用的 slice、map 等。
+func valueBytes[T any](p *T) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(p)), unsafe.Sizeof(*p))
+}
+
+// checkReadOnly 只比较 valueBytes，因此只能发现对 *p 自身的修改。
+func checkReadOnly[T any](p *T, before []byte) {
+	if !bytes.Equal(before, valueBytes(p)) {
+		panic("tsync.RWMutexValue: value modified under read lock")
+	}
 }
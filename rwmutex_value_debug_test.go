@@ -0,0 +1,31 @@
+//go:build tsync_debug
+
+package tsync
+
+import "testing"
+
+func TestRWMutexValue_RLockPtr_WriteDetected(t *testing.T) {
+	type config struct {
+		Port int
+	}
+
+	mv := NewRWMutexValue(config{Port: 8080})
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+
+	mv.RLockPtr(func(v *config) {
+		v.Port = 9090
+	})
+}
+
+func TestRWMutexValue_RLockPtr_ReadOnly(t *testing.T) {
+	mv := NewRWMutexValue([]int{1, 2, 3})
+
+	mv.RLockPtr(func(v *[]int) {
+		_ = len(*v)
+	})
+}
@@ -1,6 +1,9 @@
 package tsync
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -174,3 +177,368 @@ func TestRWMutexValue_GenericType(t *testing.T) {
 		t.Fatalf("unexpected value: %d", got)
 	}
 }
+
+func TestRWMutexValue_LockErr(t *testing.T) {
+	mv := NewRWMutexValue(1)
+	errFail := errors.New("fail")
+
+	err := mv.LockErr(func(v *int) error {
+		*v = 2
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("expected %v, got %v", errFail, err)
+	}
+
+	err = mv.RLockErr(func(v int) error {
+		if v != 2 {
+			return errFail
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestWithRLock_WithWLock(t *testing.T) {
+	type config struct {
+		Host string
+		Port int
+	}
+
+	mv := NewRWMutexValue(config{Host: "localhost", Port: 8080})
+
+	port := WithWLock(mv, func(v *config) int {
+		v.Port++
+		return v.Port
+	})
+	if port != 8081 {
+		t.Fatalf("expected 8081, got %d", port)
+	}
+
+	addr := WithRLock(mv, func(v config) string {
+		return fmt.Sprintf("%s:%d", v.Host, v.Port)
+	})
+	if addr != "localhost:8081" {
+		t.Fatalf("unexpected addr %q", addr)
+	}
+}
+
+func TestRWMutexValue_TryLock(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	mv.RLock(func(v int) {
+		if !mv.TryRLock(func(v int) {}) {
+			t.Fatalf("expected TryRLock to succeed while reading")
+		}
+		if mv.TryLock(func(v *int) {}) {
+			t.Fatalf("expected TryLock to fail while reading")
+		}
+	})
+
+	if !mv.TryLock(func(v *int) { *v = 1 }) {
+		t.Fatalf("expected TryLock to succeed")
+	}
+}
+
+func TestRWMutexValue_LockCtx(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	release := make(chan struct{})
+	locked := make(chan struct{})
+	go mv.Lock(func(v *int) {
+		close(locked)
+		<-release
+	})
+	<-locked
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := mv.RLockCtx(ctx, func(v int) {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if err := mv.LockCtx(ctx, func(v *int) {}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	close(release)
+
+	if err := mv.RLockCtx(context.Background(), func(v int) {}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestRWMutexValue_RLockPtr(t *testing.T) {
+	type config struct {
+		Hosts [128]string
+		Port  int
+	}
+
+	mv := NewRWMutexValue(config{Port: 8080})
+
+	var got *config
+	mv.RLockPtr(func(v *config) {
+		got = v
+		if v.Port != 8080 {
+			t.Fatalf("expected 8080, got %d", v.Port)
+		}
+	})
+
+	mv.Lock(func(v *config) {
+		if v != got {
+			t.Fatalf("expected RLockPtr to pass the stored value without copying")
+		}
+	})
+
+	port := WithRLockPtr(mv, func(v *config) int {
+		return v.Port
+	})
+	if port != 8080 {
+		t.Fatalf("expected 8080, got %d", port)
+	}
+}
+
+func TestRWMutexValue_RLockUpgradable(t *testing.T) {
+	mv := NewRWMutexValue(map[string]int{})
+
+	mv.RLockUpgradable(func(v map[string]int, upgrade func(func(v *map[string]int))) {
+		if _, ok := v["a"]; ok {
+			return
+		}
+		upgrade(func(v *map[string]int) {
+			(*v)["a"] = 1
+		})
+	})
+
+	if v := mv.Load()["a"]; v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+}
+
+func TestRWMutexValue_RLockUpgradable_SharedWithReaders(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	mv.RLockUpgradable(func(v int, upgrade func(func(v *int))) {
+		if !mv.TryRLock(func(v int) {}) {
+			t.Fatalf("expected readers to coexist with upgradable reader")
+		}
+		if mv.TryLock(func(v *int) {}) {
+			t.Fatalf("expected writers to be excluded")
+		}
+	})
+}
+
+func TestRWMutexValue_RLockUpgradable_Exclusive(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	const goroutines = 10
+	var current, max atomic.Int32
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			mv.RLockUpgradable(func(v int, upgrade func(func(v *int))) {
+				c := current.Add(1)
+				for {
+					m := max.Load()
+					if c <= m || max.CompareAndSwap(m, c) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				upgrade(func(v *int) {
+					*v++
+				})
+				current.Add(-1)
+			})
+		}()
+	}
+
+	wg.Wait()
+
+	if max.Load() != 1 {
+		t.Fatalf("expected at most one upgradable reader, got %d", max.Load())
+	}
+	if v := mv.Load(); v != goroutines {
+		t.Fatalf("expected %d, got %d", goroutines, v)
+	}
+}
+
+func TestRWMutexValue_Upgrade_WaitsForReaders(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	readerIn := make(chan struct{})
+	releaseReader := make(chan struct{})
+	go mv.RLock(func(v int) {
+		close(readerIn)
+		<-releaseReader
+	})
+	<-readerIn
+
+	upgraded := make(chan struct{})
+	go mv.RLockUpgradable(func(v int, upgrade func(func(v *int))) {
+		upgrade(func(v *int) {
+			*v = 1
+		})
+		close(upgraded)
+	})
+
+	select {
+	case <-upgraded:
+		t.Fatalf("expected upgrade to wait for reader")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(releaseReader)
+
+	select {
+	case <-upgraded:
+	case <-time.After(time.Second):
+		t.Fatalf("expected upgrade to proceed after reader left")
+	}
+}
+
+func TestRWMutexValue_LockDowngrade(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	mv.LockDowngrade(func(v *int) {
+		*v = 1
+	}, func(v int) {
+		if v != 1 {
+			t.Fatalf("expected 1, got %d", v)
+		}
+		if !mv.TryRLock(func(v int) {}) {
+			t.Fatalf("expected readers to enter after downgrade")
+		}
+		if mv.TryLock(func(v *int) {}) {
+			t.Fatalf("expected writers to be excluded after downgrade")
+		}
+	})
+
+	if !mv.TryLock(func(v *int) {}) {
+		t.Fatalf("expected lock to be released")
+	}
+}
+
+func TestRWMutexValue_Load_WithClone(t *testing.T) {
+	mv := NewRWMutexValue([]int{1, 2}, WithClone(func(v []int) []int {
+		return append([]int(nil), v...)
+	}))
+
+	s := mv.Load()
+	s[0] = 100
+
+	if v := mv.Load()[0]; v != 1 {
+		t.Fatalf("expected Load to return a copy, got %d", v)
+	}
+}
+
+func TestRWMutexValue_OnChange(t *testing.T) {
+	mv := NewRWMutexValue(0)
+
+	var got []int
+	mv.OnChange(func(old, new int) {
+		got = append(got, new)
+	})
+
+	mv.RLock(func(v int) {})
+	mv.Store(1)
+	mv.RLockUpgradable(func(v int, upgrade func(func(v *int))) {
+		upgrade(func(v *int) { *v = 2 })
+		if len(got) != 1 {
+			t.Errorf("expected callback to run after the lock is released")
+		}
+	})
+	mv.LockDowngrade(func(v *int) { *v = 3 }, func(v int) {})
+	mv.Lock(func(v *int) {})
+
+	want := []int{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRWMutexValue_Policy_WriterProgress(t *testing.T) {
+	for _, policy := range []RWPolicy{RWPhaseFair, RWWriterPreferring} {
+		mv := NewRWMutexValue(0, WithRWPolicy(policy))
+
+		// 读者持续到来且互相重叠，锁始终有读者持有
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		const readers = 4
+		wg.Add(readers)
+		for i := 0; i < readers; i++ {
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					mv.RLock(func(v int) {
+						time.Sleep(time.Millisecond)
+					})
+				}
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		for i := 0; i < 10; i++ {
+			done := make(chan struct{})
+			go func() {
+				mv.Update(func(old int) int { return old + 1 })
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("policy %d: writer starved by continuous readers", policy)
+			}
+		}
+
+		close(stop)
+		wg.Wait()
+
+		if got := mv.Load(); got != 10 {
+			t.Fatalf("policy %d: expected 10, got %d", policy, got)
+		}
+	}
+}
+
+func TestRWMutexValue_Policy_ReaderPreferring(t *testing.T) {
+	mv := NewRWMutexValue(0, WithRWPolicy(RWReaderPreferring))
+
+	readerIn := make(chan struct{})
+	release := make(chan struct{})
+	go mv.RLock(func(v int) {
+		close(readerIn)
+		<-release
+	})
+	<-readerIn
+
+	written := make(chan struct{})
+	go func() {
+		mv.Store(1)
+		close(written)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 写者在等待，但新读者仍可进入
+	if !mv.TryRLock(func(v int) {}) {
+		t.Fatalf("expected reader to enter while a writer waits")
+	}
+
+	close(release)
+	<-written
+}
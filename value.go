@@ -0,0 +1,18 @@
+package tsync
+
+// Value 是各种并发安全值容器的公共接口，便于在不同实现之间切换和对比。
+type Value[T any] interface {
+	Load() T
+	Store(v T)
+	Swap(v T) (old T)
+	Update(fn func(old T) T) T
+}
+
+var (
+	_ Value[int] = (*AtomicValue[int])(nil)
+	_ Value[int] = (*MutexValue[int])(nil)
+	_ Value[int] = (*RWMutexValue[int])(nil)
+	_ Value[int] = (*ReentrantMutexValue[int])(nil)
+	_ Value[int] = (*COWValue[int])(nil)
+	_ Value[int] = (*WatchableValue[int])(nil)
+)
@@ -0,0 +1,99 @@
+package tsync
+
+import (
+	"sync"
+	"testing"
+)
+
+func cloneInt(v int) int {
+	return v
+}
+
+func TestValue_Implementations(t *testing.T) {
+	impls := map[string]func(v int) Value[int]{
+		"AtomicValue":         func(v int) Value[int] { return NewAtomicValue(v) },
+		"MutexValue":          func(v int) Value[int] { return NewMutexValue(v) },
+		"RWMutexValue":        func(v int) Value[int] { return NewRWMutexValue(v) },
+		"ReentrantMutexValue": func(v int) Value[int] { return NewReentrantMutexValue(v) },
+		"COWValue":            func(v int) Value[int] { return NewCOWValue(v, cloneInt) },
+		"WatchableValue":      func(v int) Value[int] { return NewWatchableValue(v) },
+	}
+
+	for name, newValue := range impls {
+		t.Run(name, func(t *testing.T) {
+			v := newValue(1)
+
+			if got := v.Load(); got != 1 {
+				t.Fatalf("expected 1, got %d", got)
+			}
+
+			v.Store(2)
+			if old := v.Swap(3); old != 2 {
+				t.Fatalf("expected old=2, got %d", old)
+			}
+
+			const goroutines = 10
+			const iterations = 100
+
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+			for i := 0; i < goroutines; i++ {
+				go func() {
+					defer wg.Done()
+					for j := 0; j < iterations; j++ {
+						v.Update(func(old int) int {
+							return old + 1
+						})
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := v.Load(); got != 3+goroutines*iterations {
+				t.Fatalf("expected %d, got %d", 3+goroutines*iterations, got)
+			}
+		})
+	}
+}
+
+func benchmarkValueLoad(b *testing.B, v Value[int]) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = v.Load()
+		}
+	})
+}
+
+func benchmarkValueUpdate(b *testing.B, v Value[int]) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.Update(func(old int) int {
+				return old + 1
+			})
+		}
+	})
+}
+
+func BenchmarkValue_Load_AtomicValue(b *testing.B) {
+	benchmarkValueLoad(b, NewAtomicValue(0))
+}
+
+func BenchmarkValue_Load_MutexValue(b *testing.B) {
+	benchmarkValueLoad(b, NewMutexValue(0))
+}
+
+func BenchmarkValue_Load_RWMutexValue(b *testing.B) {
+	benchmarkValueLoad(b, NewRWMutexValue(0))
+}
+
+func BenchmarkValue_Update_AtomicValue(b *testing.B) {
+	benchmarkValueUpdate(b, NewAtomicValue(0))
+}
+
+func BenchmarkValue_Update_MutexValue(b *testing.B) {
+	benchmarkValueUpdate(b, NewMutexValue(0))
+}
+
+func BenchmarkValue_Update_RWMutexValue(b *testing.B) {
+	benchmarkValueUpdate(b, NewRWMutexValue(0))
+}
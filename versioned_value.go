@@ -0,0 +1,65 @@
+package tsync
+
+// VersionedValue 为每次写入分配递增的版本号，用于跨越 I/O 的读-改-写流程中
+// 检测丢失更新（乐观并发控制）。零值可直接使用，初始版本号为 0。
+type VersionedValue[T any] struct {
+	v AtomicValue[versioned[T]]
+}
+
+type versioned[T any] struct {
+	v       T
+	version uint64
+}
+
+func NewVersionedValue[T any](v T) *VersionedValue[T] {
+	vv := &VersionedValue[T]{}
+	vv.v.Store(versioned[T]{v: v})
+	return vv
+}
+
+func (vv *VersionedValue[T]) Load() (T, uint64) {
+	s := vv.v.Load()
+	return s.v, s.version
+}
+
+func (vv *VersionedValue[T]) Version() uint64 {
+	if p := vv.v.p.Load(); p != nil {
+		return p.version
+	}
+	return 0
+}
+
+// Store 无条件写入并返回新版本号。
+func (vv *VersionedValue[T]) Store(v T) (version uint64) {
+	return vv.v.Update(func(old versioned[T]) versioned[T] {
+		return versioned[T]{v: v, version: old.version + 1}
+	}).version
+}
+
+// StoreIfVersion 仅当当前版本号仍为 version 时写入 v。
+func (vv *VersionedValue[T]) StoreIfVersion(v T, version uint64) (stored bool) {
+	return vv.v.CompareAndSwapFunc(
+		versioned[T]{version: version},
+		versioned[T]{v: v, version: version + 1},
+		func(x, y versioned[T]) bool {
+			return x.version == y.version
+		},
+	)
+}
+
+// Read 以指针形式把当前快照交给 fn 并返回其版本号，既不加锁也不复制 T，
+// 适合较大的结构体。快照在写入后不会被修改，fn 也不得修改 *v。
+// 与顺序锁的用法相同：读取后可用 Changed 校验期间是否有写入。
+func (vv *VersionedValue[T]) Read(fn func(v *T)) (version uint64) {
+	p := vv.v.p.Load()
+	if p == nil {
+		p = &versioned[T]{}
+	}
+	fn(&p.v)
+	return p.version
+}
+
+// Changed 报告自 version 之后是否有过写入。
+func (vv *VersionedValue[T]) Changed(version uint64) bool {
+	return vv.Version() != version
+}
@@ -0,0 +1,119 @@
+package tsync
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVersionedValue_LoadStore(t *testing.T) {
+	vv := NewVersionedValue("a")
+
+	if v, ver := vv.Load(); v != "a" || ver != 0 {
+		t.Fatalf("unexpected value %q version %d", v, ver)
+	}
+
+	if ver := vv.Store("b"); ver != 1 {
+		t.Fatalf("expected version 1, got %d", ver)
+	}
+	if v, ver := vv.Load(); v != "b" || ver != 1 {
+		t.Fatalf("unexpected value %q version %d", v, ver)
+	}
+}
+
+func TestVersionedValue_StoreIfVersion(t *testing.T) {
+	var vv VersionedValue[int]
+
+	_, ver := vv.Load()
+	if !vv.StoreIfVersion(1, ver) {
+		t.Fatalf("expected store to succeed")
+	}
+
+	// 版本已过期
+	if vv.StoreIfVersion(2, ver) {
+		t.Fatalf("expected stale store to fail")
+	}
+	if v, ver := vv.Load(); v != 1 || ver != 1 {
+		t.Fatalf("unexpected value %d version %d", v, ver)
+	}
+}
+
+func TestVersionedValue_NoLostUpdates(t *testing.T) {
+	vv := NewVersionedValue(0)
+
+	const goroutines = 20
+	const iterations = 50
+
+	var retries atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				for {
+					v, ver := vv.Load()
+					if vv.StoreIfVersion(v+1, ver) {
+						break
+					}
+					retries.Add(1)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if v, ver := vv.Load(); v != goroutines*iterations || ver != goroutines*iterations {
+		t.Fatalf("unexpected value %d version %d", v, ver)
+	}
+}
+
+func TestVersionedValue_Read(t *testing.T) {
+	type table struct {
+		routes [64]string
+	}
+
+	vv := NewVersionedValue(table{})
+
+	var first *table
+	ver := vv.Read(func(v *table) {
+		first = v
+	})
+	if ver != 0 || vv.Changed(ver) {
+		t.Fatalf("expected unchanged version 0, got %d", ver)
+	}
+
+	var next table
+	next.routes[0] = "/"
+	vv.Store(next)
+
+	if !vv.Changed(ver) {
+		t.Fatalf("expected Changed to report the write")
+	}
+
+	// 旧快照不受后续写入影响
+	if first.routes[0] != "" {
+		t.Fatalf("expected old snapshot to be immutable")
+	}
+
+	vv.Read(func(v *table) {
+		if v.routes[0] != "/" {
+			t.Fatalf("unexpected value %q", v.routes[0])
+		}
+	})
+}
+
+func TestVersionedValue_Read_ZeroValue(t *testing.T) {
+	var vv VersionedValue[[]int]
+
+	ver := vv.Read(func(v *[]int) {
+		if *v != nil {
+			t.Fatalf("expected nil slice")
+		}
+	})
+	if ver != 0 {
+		t.Fatalf("expected version 0, got %d", ver)
+	}
+}
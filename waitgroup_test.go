@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestWaitGroup_GoWait(t *testing.T) {
@@ -121,6 +122,7 @@ func TestWaitGroup_GoCtx_CancelDuringExecution(t *testing.T) {
 		case <-ctx.Done():
 			// 即使在函数内部，也应该响应上下文取消
 			return
+		case <-time.After(time.Second):
 		}
 		called.Store(true)
 	})
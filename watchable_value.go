@@ -0,0 +1,160 @@
+package tsync
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WatchableValue 在每次 Store/Swap/Update 时递增版本号并唤醒等待者，
+// 读取仍然只是一次原子加载。零值可直接使用，初始版本号为 0。
+type WatchableValue[T any] struct {
+	mu  sync.Mutex
+	cur atomic.Pointer[watchState[T]]
+}
+
+type watchState[T any] struct {
+	v       T
+	version uint64
+	changed chan struct{} // 被新值取代时关闭
+}
+
+func NewWatchableValue[T any](v T) *WatchableValue[T] {
+	w := &WatchableValue[T]{}
+	w.cur.Store(&watchState[T]{v: v, changed: make(chan struct{})})
+	return w
+}
+
+func (w *WatchableValue[T]) Load() T {
+	return w.state().v
+}
+
+func (w *WatchableValue[T]) LoadVersion() (T, uint64) {
+	s := w.state()
+	return s.v, s.version
+}
+
+func (w *WatchableValue[T]) Store(v T) {
+	w.Swap(v)
+}
+
+func (w *WatchableValue[T]) Swap(v T) (old T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.publish(v).v
+}
+
+// Update 以 fn 的返回值替换当前值并返回新值，fn 在内部锁中执行。
+func (w *WatchableValue[T]) Update(fn func(old T) T) T {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	v := fn(w.stateLocked().v)
+	w.publish(v)
+	return v
+}
+
+// WaitForChange 阻塞直到版本号不同于 since，返回最新的值和版本号。
+func (w *WatchableValue[T]) WaitForChange(ctx context.Context, since uint64) (T, uint64, error) {
+	for {
+		s := w.state()
+		if s.version != since {
+			return s.v, s.version, nil
+		}
+
+		select {
+		case <-s.changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, since, ctx.Err()
+		}
+	}
+}
+
+// Changes 返回一个在值变化时收到最新值的 channel，ctx 结束后关闭。
+// 消费者较慢时中间值会被合并，只保证收到最新值。
+func (w *WatchableValue[T]) Changes(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	since := w.state().version
+
+	go func() {
+		defer close(ch)
+
+		for {
+			if _, _, err := w.WaitForChange(ctx, since); err != nil {
+				return
+			}
+
+			// 发送期间若再次变化，改为发送更新后的值
+			s := w.state()
+			for sent := false; !sent; {
+				select {
+				case ch <- s.v:
+					sent = true
+				case <-s.changed:
+					s = w.state()
+				case <-ctx.Done():
+					return
+				}
+			}
+			since = s.version
+		}
+	}()
+
+	return ch
+}
+
+// Subscribe 在独立的 goroutine 中按顺序对每次变化调用 fn（中间值可能被合并）。
+// 返回的 unsubscribe 会等待正在执行的 fn 结束，因此不能在 fn 内调用。
+func (w *WatchableValue[T]) Subscribe(fn func(v T)) (unsubscribe func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := w.Changes(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		for v := range changes {
+			if ctx.Err() != nil {
+				return
+			}
+			fn(v)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (w *WatchableValue[T]) state() *watchState[T] {
+	if s := w.cur.Load(); s != nil {
+		return s
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stateLocked()
+}
+
+func (w *WatchableValue[T]) stateLocked() *watchState[T] {
+	s := w.cur.Load()
+	if s == nil {
+		s = &watchState[T]{changed: make(chan struct{})}
+		w.cur.Store(s)
+	}
+	return s
+}
+
+// publish 需持有 mu，返回被取代的状态。
+func (w *WatchableValue[T]) publish(v T) *watchState[T] {
+	prev := w.stateLocked()
+	w.cur.Store(&watchState[T]{
+		v:       v,
+		version: prev.version + 1,
+		changed: make(chan struct{}),
+	})
+	close(prev.changed)
+	return prev
+}
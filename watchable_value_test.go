@@ -0,0 +1,179 @@
+package tsync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchableValue_LoadStore(t *testing.T) {
+	w := NewWatchableValue("a")
+
+	if v, ver := w.LoadVersion(); v != "a" || ver != 0 {
+		t.Fatalf("unexpected value %q version %d", v, ver)
+	}
+
+	w.Store("b")
+	if old := w.Swap("c"); old != "b" {
+		t.Fatalf("expected old=b, got %q", old)
+	}
+
+	if v, ver := w.LoadVersion(); v != "c" || ver != 2 {
+		t.Fatalf("unexpected value %q version %d", v, ver)
+	}
+}
+
+func TestWatchableValue_ZeroValue(t *testing.T) {
+	var w WatchableValue[int]
+
+	if v := w.Load(); v != 0 {
+		t.Fatalf("expected 0, got %d", v)
+	}
+	if v := w.Update(func(old int) int { return old + 1 }); v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+}
+
+func TestWatchableValue_WaitForChange(t *testing.T) {
+	w := NewWatchableValue(0)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		w.Store(42)
+	}()
+
+	v, ver, err := w.WaitForChange(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if v != 42 || ver != 1 {
+		t.Fatalf("unexpected value %d version %d", v, ver)
+	}
+
+	// 版本已落后时立即返回
+	v, ver, err = w.WaitForChange(context.Background(), 0)
+	if err != nil || v != 42 || ver != 1 {
+		t.Fatalf("unexpected value %d version %d err %v", v, ver, err)
+	}
+}
+
+func TestWatchableValue_WaitForChange_Cancel(t *testing.T) {
+	w := NewWatchableValue(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := w.WaitForChange(ctx, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestWatchableValue_Changes(t *testing.T) {
+	w := NewWatchableValue(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := w.Changes(ctx)
+
+	w.Store(1)
+	if v := <-ch; v != 1 {
+		t.Fatalf("expected 1, got %d", v)
+	}
+
+	// 慢消费者只会收到最新值
+	w.Store(2)
+	w.Store(3)
+	deadline := time.After(time.Second)
+	for v := 0; v != 3; {
+		select {
+		case v = <-ch:
+		case <-deadline:
+			t.Fatalf("expected latest value 3")
+		}
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchableValue_Subscribe(t *testing.T) {
+	w := NewWatchableValue(0)
+
+	var mu sync.Mutex
+	var got []int
+	unsubscribe := w.Subscribe(func(v int) {
+		mu.Lock()
+		got = append(got, v)
+		mu.Unlock()
+	})
+
+	for i := 1; i <= 5; i++ {
+		w.Store(i)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		last := 0
+		if len(got) > 0 {
+			last = got[len(got)-1]
+		}
+		mu.Unlock()
+		if last == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected subscriber to observe 5")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	unsubscribe()
+
+	mu.Lock()
+	n := len(got)
+	for i := 1; i < n; i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("expected increasing values, got %v", got)
+		}
+	}
+	mu.Unlock()
+
+	w.Store(6)
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != n {
+		t.Fatalf("expected no callbacks after unsubscribe, got %v", got)
+	}
+}
+
+func TestWatchableValue_ConcurrentWatchers(t *testing.T) {
+	w := NewWatchableValue(0)
+
+	const watchers = 10
+	var woken atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(watchers)
+
+	for i := 0; i < watchers; i++ {
+		go func() {
+			defer wg.Done()
+			if _, _, err := w.WaitForChange(context.Background(), 0); err == nil {
+				woken.Add(1)
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	w.Store(1)
+	wg.Wait()
+
+	if woken.Load() != watchers {
+		t.Fatalf("expected %d watchers woken, got %d", watchers, woken.Load())
+	}
+}